@@ -2,24 +2,34 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
-	"github.com/twmb/franz-go/pkg/kgo"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 
 	"order-service-wb/internal/api"
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/bootstrap"
 	"order-service-wb/internal/cache"
+	"order-service-wb/internal/codec"
+	"order-service-wb/internal/health"
+	"order-service-wb/internal/ingest"
 	"order-service-wb/internal/kafka"
-	"order-service-wb/internal/models"
+	"order-service-wb/internal/lifecycle"
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/metrics"
+	"order-service-wb/internal/outbox"
 	"order-service-wb/internal/repository"
+	"order-service-wb/internal/schema"
 	"order-service-wb/internal/service"
+	"order-service-wb/internal/tracing"
 	"order-service-wb/pkg/config"
 )
 
@@ -28,71 +38,161 @@ func main() {
 	defer stop()
 	conf := config.NewConfig()
 
-	db, err := sqlx.Connect("postgres", conf.DbConfig.GetDSN())
-	defer func(db *sqlx.DB) {
-		err = db.Close()
-		if err != nil {
-			log.Fatalf("failed to close database connection: %v", err)
-		}
-	}(db)
+	logger, err := logging.New(os.Stdout, conf.Log.Format, conf.Log.Level)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		log.Fatalf("failed to init logger: %v", err)
 	}
 
-	repo := repository.NewOrderRepository(db)
-	c := cache.NewCache(conf.Cache.Size)
-	serv := service.NewOrderService(repo, c)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    conf.Tracing.Exporter,
+		Endpoint:    conf.Tracing.Endpoint,
+		File:        conf.Tracing.File,
+		SampleRatio: conf.Tracing.SampleRatio,
+	})
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init tracing", err)
+	}
 
-	if err = serv.LoadCache(context.Background(), conf.Cache.Size); err != nil {
-		log.Fatalf("failed to load cache: %v", err)
+	db, err := connectDB(conf.DbConfig.GetDSN())
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to connect to database", err)
 	}
 
-	handler := api.NewHandler(serv)
+	metrics.RegisterDB(db.DB, conf.DbConfig.Database)
 
-	go func() {
-		cons, err := kafka.NewConsumer([]string{conf.Kafka.Broker}, conf.Kafka.Group, conf.Kafka.Topic)
-		if err != nil {
-			log.Fatalf("failed to init kafka consumer: %v", err)
-		}
-		defer cons.Close()
-
-		cons.Run(ctx, func(msg *kgo.Record) error {
-			var order models.Order
-			if err = json.Unmarshal(msg.Value, &order); err != nil {
-				log.Printf("invalid Kafka message: %v", err)
-				return err
-			}
-
-			if err = serv.CreateOrder(ctx, &order); err != nil {
-				log.Printf("failed to store order: %v", err)
-				return err
-			}
-
-			log.Printf("Kafka: successfully processed order %s", order.OrderUID)
-			return nil
-		})
-	}()
+	repo := repository.NewOrderRepository(db, logger)
+	c, err := cache.New(cache.Options{
+		Policy:   conf.Cache.Policy,
+		Size:     conf.Cache.Size,
+		Shards:   conf.Cache.Shards,
+		TTL:      conf.Cache.TTL,
+		MaxBytes: conf.Cache.MaxBytes,
+	})
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init cache", err)
+	}
+	metrics.RegisterCache(c)
+
+	ruleEngine, err := bootstrap.NewRuleEngine(conf.Validation)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init business rules", err)
+	}
+
+	serv := service.NewOrderService(repo, c, ruleEngine, conf.Cache.NotFoundTTL, logger)
+
+	schemas, err := schema.OpenFileRegistry(conf.Schemas.Dir)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to open schema registry", err)
+	}
+	codecs, err := codec.Default(schemas)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init order codecs", err)
+	}
+
+	deadLetter, err := kafka.NewProducer([]string{conf.Kafka.Broker}, conf.Kafka.DeadLetterTopic)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init kafka dead-letter producer", err)
+	}
+
+	retry := kafka.RetryPolicy{
+		MaxAttempts: conf.Kafka.Retry.MaxAttempts,
+		BaseDelay:   conf.Kafka.Retry.BaseDelay,
+		MaxDelay:    conf.Kafka.Retry.MaxDelay,
+		Jitter:      conf.Kafka.Retry.Jitter,
+		Retryable:   apperrors.IsTemporary,
+	}
+
+	commit := kafka.CommitPolicy{
+		Interval: conf.Kafka.Commit.Interval,
+		Batch:    conf.Kafka.Commit.Batch,
+	}
+
+	cons, err := kafka.NewConsumer([]string{conf.Kafka.Broker}, conf.Kafka.Group, conf.Kafka.Topic, deadLetter, retry, commit, logger)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init kafka consumer", err)
+	}
+
+	outboxProducer, err := kafka.NewProducer([]string{conf.Kafka.Broker}, conf.Outbox.Topic)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init kafka outbox producer", err)
+	}
+	relay := outbox.NewRelay(repository.NewOutboxRepository(db, logger), outboxProducer, conf.Outbox.Interval, conf.Outbox.Batch, logger)
+
+	var cacheWarm health.Flag
+	readiness := health.NewChecker(conf.Server.HealthTimeout)
+	readiness.Register("database", db.PingContext)
+	readiness.Register("kafka", cons.Ping)
+	readiness.Register("cache", cacheWarm.Check)
+
+	handler := api.NewHandler(serv, readiness, conf.Server.AdminToken, logger)
 
 	server := &http.Server{
 		Addr:    ":" + conf.Server.Port,
 		Handler: handler.InitRouter(),
 	}
 
+	// The server starts before the cache is warm so that liveness probes
+	// succeed during warm-up; /readyz keeps reporting 503 until it is done.
 	go func() {
-		log.Println("Starting server on :" + conf.Server.Port)
-		if err = server.ListenAndServe(); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			log.Fatalf("failed to start server: %v", err)
+		logger.Info("starting server", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			bootstrap.Fatal(logger, "failed to start server", err)
 		}
 	}()
 
+	if err = serv.LoadCache(context.Background(), conf.Cache.Size); err != nil {
+		bootstrap.Fatal(logger, "failed to load cache", err)
+	}
+	cacheWarm.Set()
+
+	go relay.Run(ctx)
+
+	go cons.Run(ctx, ingest.New(serv, codecs, logger).Handle)
+
 	<-ctx.Done()
-	log.Println("Shutting down server...")
+	logger.Info("shutting down")
+
+	// Intake stops first so that nothing new reaches the database while the
+	// in-flight work drains; the database is closed last but for tracing,
+	// which flushes the spans of the shutdown itself.
+	lc := lifecycle.NewManager(logger)
+	lc.Add("kafka consumer", conf.Shutdown.Consumer, cons.Shutdown)
+	lc.Add("http server", conf.Shutdown.HTTP, server.Shutdown)
+	lc.Add("outbox relay", conf.Shutdown.Outbox, relay.Shutdown)
+	lc.Add("outbox producer", conf.Shutdown.Producer, func(context.Context) error {
+		outboxProducer.Close()
+		return nil
+	})
+	lc.Add("dead-letter producer", conf.Shutdown.Producer, func(context.Context) error {
+		deadLetter.Close()
+		return nil
+	})
+	lc.Add("database", conf.Shutdown.Database, func(context.Context) error {
+		return db.Close()
+	})
+	lc.Add("tracing", conf.Shutdown.Tracing, shutdownTracing)
+
+	if err = lc.Shutdown(context.Background()); err != nil {
+		bootstrap.Fatal(logger, "shutdown completed with errors", err)
+	}
+	logger.Info("service gracefully stopped")
+}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// connectDB opens the database through otelsql so that every statement is
+// traced as a child of the calling span.
+func connectDB(dsn string) (*sqlx.DB, error) {
+	sqlDB, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	if err = server.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("failed to gracefully shutdown server: %v", err)
+	db := sqlx.NewDb(sqlDB, "postgres")
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
 	}
-	log.Println("Server gracefully stopped")
+	return db, nil
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/rules"
+)
+
+func TestOrderFactory_SeededAndValid(t *testing.T) {
+	s := defaultScenario()
+	s.Seed = 42
+	s.Items = ItemsRange{Min: 1, Max: 5}
+	s.Currencies = map[string]float64{"USD": 1, "EUR": 1, "RUB": 0}
+
+	engine, err := rules.NewEngine(map[string]rules.Setting{
+		"goods_total":               {Enabled: true},
+		"amount":                    {Enabled: true},
+		"item_total_price":          {Enabled: true},
+		"transaction_matches_order": {Enabled: true},
+	})
+	require.NoError(t, err)
+
+	a, b := newOrderFactory(s), newOrderFactory(s)
+	for range 50 {
+		order, same := a.next(), b.next()
+		assert.Equal(t, order, same)
+
+		assert.GreaterOrEqual(t, len(order.Items), 1)
+		assert.LessOrEqual(t, len(order.Items), 5)
+		assert.Contains(t, []string{"USD", "EUR"}, order.Payment.Currency)
+
+		_, err = engine.Validate(&order)
+		require.NoError(t, err)
+	}
+}
+
+func TestRun_StopsAtCount(t *testing.T) {
+	s := defaultScenario()
+	s.Count = 20
+	s.Rate = 0
+	s.Concurrency = 4
+
+	var sent atomic.Int32
+	rep := run(context.Background(), s, newOrderFactory(s), func(context.Context, *models.Order) error {
+		sent.Add(1)
+		return nil
+	})
+
+	assert.Equal(t, int32(20), sent.Load())
+	assert.Equal(t, 20, rep.Sent)
+	assert.Zero(t, rep.Failed)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
+
+func TestLoadScenario(t *testing.T) {
+	s, err := loadScenario("../../scenarios/peak.yaml")
+	require.NoError(t, err)
+	require.NoError(t, s.validate())
+
+	assert.Equal(t, 2*time.Minute, s.Duration)
+	assert.Equal(t, ItemsRange{Min: 1, Max: 8}, s.Items)
+	assert.Equal(t, map[string]float64{"RUB": 6, "USD": 3, "EUR": 1}, s.Currencies)
+	assert.Equal(t, map[string]float64{"wbpay": 7, "sberpay": 2, "tinkoff": 1}, s.Providers)
+	assert.Equal(t, "protobuf", s.Format)
+}
+
+func TestLoadScenario_DefaultMixes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("count: 10\ncurrencies:\n  eur: 2\n"), 0o644))
+
+	s, err := loadScenario(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]float64{"EUR": 2}, s.Currencies)
+	assert.Equal(t, defaultProviders(), s.Providers)
+}
+
+func TestLoadScenario_DurationOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("duration: 5m\n"), 0o644))
+
+	s, err := loadScenario(path)
+	require.NoError(t, err)
+	s.setDefaults()
+
+	assert.Equal(t, 5*time.Minute, s.Duration)
+	assert.Zero(t, s.Count, "a duration alone must not be capped by the default count")
+}
+
+func TestScenario_DefaultCount(t *testing.T) {
+	s, err := loadScenario("")
+	require.NoError(t, err)
+	s.setDefaults()
+
+	assert.Equal(t, defaultCount, s.Count)
+	assert.NotZero(t, s.Seed)
+}
@@ -1,107 +1,216 @@
+// Command generator produces fake orders to the order topic. It doubles as a
+// load generator: a scenario file or flags set the volume, rate, concurrency
+// and payload mix, and a report of the achieved throughput and produce
+// latencies is printed at the end.
 package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
 	"log"
-	"math/rand"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
 
+	"order-service-wb/internal/codec"
 	"order-service-wb/internal/kafka"
 	"order-service-wb/internal/models"
+	"order-service-wb/internal/schema"
+	"order-service-wb/internal/tracing"
 	"order-service-wb/pkg/config"
 )
 
+// formats maps the values of -format to the content types of the codecs.
+var formats = map[string]string{
+	"json":     codec.ContentTypeJSON,
+	"protobuf": codec.ContentTypeProtobuf,
+	"avro":     codec.ContentTypeAvro,
+}
+
 func main() {
+	scenarioFile := flag.String("scenario", "", "YAML scenario file; flags override its values")
+	flag.Int("count", 0, "number of orders to send, 0 for no limit")
+	flag.Duration("duration", 0, "stop after this long, 0 for no limit")
+	flag.Float64("rate", 0, "target orders per second, 0 for as fast as possible")
+	flag.Int("concurrency", 0, "number of concurrent senders")
+	flag.Int64("seed", 0, "seed of the generated orders, 0 for a random one")
+	flag.String("format", "", "payload format: json, protobuf or avro")
+	flag.String("items", "", "items per order, e.g. 1-5")
+	flag.String("currencies", "", "currency mix, e.g. USD=3,EUR=1")
+	flag.String("providers", "", "payment provider mix, e.g. wbpay=4,sberpay=1")
+	flag.Parse()
+
+	s, err := loadScenario(*scenarioFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = applyFlags(&s); err != nil {
+		log.Fatal(err)
+	}
+	s.setDefaults()
+	if err = s.validate(); err != nil {
+		log.Fatalf("invalid scenario: %v", err)
+	}
+
 	cfg := config.NewConfig()
 
+	schemas, err := schema.OpenFileRegistry(cfg.Schemas.Dir)
+	if err != nil {
+		log.Fatalf("failed to open schema registry: %v", err)
+	}
+	codecs, err := codec.Default(schemas)
+	if err != nil {
+		log.Fatalf("failed to init codecs: %v", err)
+	}
+	contentType := formats[s.Format]
+	enc, err := codecs.Lookup(contentType)
+	if err != nil {
+		log.Fatalf("failed to init %s codec: %v", s.Format, err)
+	}
+
+	headers := []kgo.RecordHeader{{Key: kafka.HeaderContentType, Value: []byte(contentType)}}
+	if sc, ok := enc.(codec.SchemaCodec); ok {
+		headers = append(headers, kgo.RecordHeader{Key: kafka.HeaderSchemaID, Value: []byte(strconv.Itoa(sc.SchemaID()))})
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("failed to init tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("failed to flush traces: %v", err)
+		}
+	}()
+
 	prod, err := kafka.NewProducer([]string{cfg.Kafka.Broker}, cfg.Kafka.Topic)
 	if err != nil {
 		log.Fatalf("failed to create Kafka producer: %v", err)
 	}
-
 	defer prod.Close()
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	for i := 0; i < 100; i++ {
-		order := generateFakeOrder()
-		data, err := json.Marshal(order)
+	log.Printf("sending orders: count=%d duration=%s rate=%g concurrency=%d format=%s seed=%d",
+		s.Count, s.Duration, s.Rate, s.Concurrency, s.Format, s.Seed)
+
+	rep := run(ctx, s, newOrderFactory(s), func(ctx context.Context, order *models.Order) error {
+		data, err := enc.Marshal(order)
 		if err != nil {
-			log.Printf("failed to marshal order: %v", err)
-			continue
+			return err
 		}
+		return prod.SendWithHeaders(ctx, order.OrderUID, data, headers...)
+	})
+
+	log.Printf("sent %d orders, %d failed in %s: %.1f orders/s",
+		rep.Sent, rep.Failed, rep.Elapsed.Round(time.Millisecond), rep.Throughput)
+	log.Printf("produce latency: p50=%s p90=%s p99=%s max=%s", rep.P50, rep.P90, rep.P99, rep.Max)
+	if rep.FirstErr != nil {
+		log.Printf("first error: %v", rep.FirstErr)
+	}
+}
 
-		if err = prod.Send(ctx, order.OrderUID, data); err != nil {
-			log.Printf("failed to send to Kafka: %v", err)
-		} else {
-			log.Printf("sent order %s to Kafka", order.OrderUID)
+// applyFlags overrides the scenario with the flags given on the command line.
+func applyFlags(s *Scenario) error {
+	var errs []error
+	flag.Visit(func(f *flag.Flag) {
+		getter := f.Value.(flag.Getter)
+		var err error
+		switch f.Name {
+		case "count":
+			s.Count = getter.Get().(int)
+			s.countSet = true
+		case "duration":
+			s.Duration = getter.Get().(time.Duration)
+		case "rate":
+			s.Rate = getter.Get().(float64)
+		case "concurrency":
+			s.Concurrency = getter.Get().(int)
+		case "seed":
+			s.Seed = getter.Get().(int64)
+		case "format":
+			s.Format = f.Value.String()
+		case "items":
+			s.Items, err = parseItems(f.Value.String())
+		case "currencies":
+			s.Currencies, err = parseWeights(f.Value.String())
+		case "providers":
+			s.Providers, err = parseWeights(f.Value.String())
 		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	})
+	return errors.Join(errs...)
+}
 
-		time.Sleep(500 * time.Millisecond)
+// run sends orders from factory with s.Concurrency workers, pacing them to
+// s.Rate, until s.Count orders were handed out, s.Duration elapsed or ctx is
+// done. Orders are generated in a single goroutine so that a seed yields the
+// same sequence whatever the concurrency.
+func run(ctx context.Context, s Scenario, factory *orderFactory, send func(context.Context, *models.Order) error) report {
+	if s.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Duration)
+		defer cancel()
 	}
-}
 
-func generateFakeOrder() models.Order {
-	uid := uuid.New().String()
-	return models.Order{
-		OrderUID:    uid,
-		TrackNumber: "WBTRACK" + randSeq(4),
-		Entry:       "WBIL",
-		Locale:      "en",
-		InternalSig: "",
-		CustomerID:  "testuser",
-		DeliverySrv: "meest",
-		ShardKey:    "1",
-		SmID:        rand.Intn(100),
-		DateCreated: time.Now().UTC(),
-		OofShard:    "1",
-		Delivery: models.Delivery{
-			Name:   "Test User",
-			Phone:  "+1234567890",
-			Zip:    "123456",
-			City:   "TestCity",
-			Addr:   "123 Test St",
-			Region: "TestRegion",
-			Email:  "test@example.com",
-		},
-		Payment: models.Payment{
-			Transaction:  uid,
-			RequestID:    "2234555",
-			Currency:     "USD",
-			Provider:     "wbpay",
-			Amount:       1000,
-			PaymentDT:    time.Now().Unix(),
-			Bank:         "alpha",
-			DeliveryCost: 500,
-			GoodsTotal:   500,
-			CustomFee:    0,
-		},
-		Items: []models.Item{
-			{
-				ChrtID:      rand.Intn(100000),
-				TrackNumber: "WBTRACK" + randSeq(4),
-				Price:       500,
-				Rid:         uuid.New().String(),
-				Name:        "Some Product",
-				Sale:        0,
-				Size:        "L",
-				TotalPrice:  500,
-				NmID:        rand.Intn(10000),
-				Brand:       "BrandName",
-				Status:      202,
-			},
-		},
+	var rec recorder
+	orders := make(chan models.Order, s.Concurrency)
+	var wg sync.WaitGroup
+	for range s.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for order := range orders {
+				start := time.Now()
+				// Orders already handed out are sent even if the run is
+				// stopping, so that every generated order is accounted for.
+				err := send(context.WithoutCancel(ctx), &order)
+				rec.record(time.Since(start), err)
+			}
+		}()
 	}
-}
 
-func randSeq(n int) string {
-	letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+	var interval time.Duration
+	if s.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / s.Rate)
 	}
-	return string(b)
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+dispatch:
+	for i := 0; s.Count == 0 || i < s.Count; i++ {
+		if interval > 0 {
+			timer.Reset(time.Until(start.Add(time.Duration(i) * interval)))
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case <-timer.C:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case orders <- factory.next():
+		}
+	}
+	close(orders)
+	wg.Wait()
+
+	return rec.report(time.Since(start))
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-service-wb/internal/models"
+)
+
+// epoch is the earliest creation time of generated orders.
+var epoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// orderFactory generates orders that pass the business rules of the service.
+// All randomness comes from one seeded source, timestamps included: they are
+// drawn from the year after epoch rather than read from the clock. Rerunning
+// a seed therefore resends identical orders, which the service accepts as
+// duplicates instead of rejecting them as conflicting versions.
+type orderFactory struct {
+	rnd        *rand.Rand
+	items      ItemsRange
+	currencies weighted
+	providers  weighted
+}
+
+func newOrderFactory(s Scenario) *orderFactory {
+	return &orderFactory{
+		rnd:        rand.New(rand.NewSource(s.Seed)),
+		items:      s.Items,
+		currencies: newWeighted(s.Currencies),
+		providers:  newWeighted(s.Providers),
+	}
+}
+
+func (f *orderFactory) next() models.Order {
+	uid := f.uuid()
+	track := "WBTRACK" + f.randSeq(4)
+	created := epoch.Add(time.Duration(f.rnd.Int63n(365*24*60*60)) * time.Second)
+
+	items := make([]models.Item, f.items.Min+f.rnd.Intn(f.items.Max-f.items.Min+1))
+	goodsTotal := 0
+	for i := range items {
+		price := 100 + f.rnd.Intn(4900)
+		sale := []int{0, 0, 10, 25, 50}[f.rnd.Intn(5)]
+		items[i] = models.Item{
+			ChrtID:      1 + f.rnd.Intn(100000),
+			TrackNumber: track,
+			Price:       price,
+			Rid:         f.uuid(),
+			Name:        "Some Product",
+			Sale:        sale,
+			Size:        []string{"S", "M", "L", "XL"}[f.rnd.Intn(4)],
+			TotalPrice:  price * (100 - sale) / 100,
+			NmID:        1 + f.rnd.Intn(10000),
+			Brand:       "BrandName",
+			Status:      202,
+		}
+		goodsTotal += items[i].TotalPrice
+	}
+	deliveryCost := 500
+
+	return models.Order{
+		OrderUID:    uid,
+		TrackNumber: track,
+		Entry:       "WBIL",
+		Locale:      "en",
+		CustomerID:  "testuser",
+		DeliverySrv: "meest",
+		ShardKey:    "1",
+		SmID:        f.rnd.Intn(100),
+		DateCreated: created,
+		OofShard:    "1",
+		Version:     1,
+		Status:      models.StatusCreated,
+		Delivery: models.Delivery{
+			Name:   "Test User",
+			Phone:  "+1234567890",
+			Zip:    "123456",
+			City:   "TestCity",
+			Addr:   "123 Test St",
+			Region: "TestRegion",
+			Email:  "test@example.com",
+		},
+		Payment: models.Payment{
+			Transaction:  uid,
+			RequestID:    f.uuid(),
+			Currency:     f.currencies.pick(f.rnd),
+			Provider:     f.providers.pick(f.rnd),
+			Amount:       goodsTotal + deliveryCost,
+			PaymentDT:    created.Unix(),
+			Bank:         "alpha",
+			DeliveryCost: deliveryCost,
+			GoodsTotal:   goodsTotal,
+		},
+		Items: items,
+	}
+}
+
+func (f *orderFactory) uuid() string {
+	id, err := uuid.NewRandomFromReader(f.rnd)
+	if err != nil {
+		// Reading from math/rand never fails.
+		panic(err)
+	}
+	return id.String()
+}
+
+func (f *orderFactory) randSeq(n int) string {
+	letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	b := make([]rune, n)
+	for i := range b {
+		b[i] = letters[f.rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// weighted picks values with probability proportional to their weights.
+type weighted struct {
+	values     []string
+	cumulative []float64
+}
+
+func newWeighted(weights map[string]float64) weighted {
+	// Sorted so that a seed picks the same values regardless of map order.
+	var w weighted
+	total := 0.0
+	for _, value := range slices.Sorted(maps.Keys(weights)) {
+		total += weights[value]
+		w.values = append(w.values, value)
+		w.cumulative = append(w.cumulative, total)
+	}
+	return w
+}
+
+func (w weighted) pick(rnd *rand.Rand) string {
+	x := rnd.Float64() * w.cumulative[len(w.cumulative)-1]
+	i := sort.Search(len(w.cumulative), func(i int) bool { return w.cumulative[i] > x })
+	return w.values[min(i, len(w.values)-1)]
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Scenario describes a load run. It is read from the YAML file given with
+// -scenario; flags set on the command line override its values.
+type Scenario struct {
+	// Count is the number of orders to send; zero means no limit. If neither
+	// count nor duration is given, defaultCount orders are sent.
+	Count int `mapstructure:"count"`
+	// Duration stops the run after this long; zero means no limit. A run
+	// with neither limit lasts until it is interrupted.
+	Duration time.Duration `mapstructure:"duration"`
+	// Rate is the target number of orders per second; zero sends as fast as
+	// the workers allow.
+	Rate        float64 `mapstructure:"rate"`
+	Concurrency int     `mapstructure:"concurrency"`
+	// Seed makes the generated orders reproducible; zero picks one at random.
+	Seed   int64  `mapstructure:"seed"`
+	Format string `mapstructure:"format"`
+
+	Items ItemsRange `mapstructure:"items"`
+	// Currencies and Providers map values to their relative weights.
+	Currencies map[string]float64 `mapstructure:"currencies"`
+	Providers  map[string]float64 `mapstructure:"providers"`
+
+	// countSet records that count was given explicitly, zero included.
+	countSet bool
+}
+
+// defaultCount is the number of orders sent when the scenario sets no limit.
+const defaultCount = 100
+
+// ItemsRange is the uniform distribution of the number of items per order.
+type ItemsRange struct {
+	Min int `mapstructure:"min"`
+	Max int `mapstructure:"max"`
+}
+
+func defaultScenario() Scenario {
+	return Scenario{
+		Rate:        2,
+		Concurrency: 1,
+		Format:      "json",
+		Items:       ItemsRange{Min: 1, Max: 1},
+		Currencies:  defaultCurrencies(),
+		Providers:   defaultProviders(),
+	}
+}
+
+func defaultCurrencies() map[string]float64 { return map[string]float64{"USD": 1} }
+
+func defaultProviders() map[string]float64 { return map[string]float64{"wbpay": 1} }
+
+// loadScenario reads the scenario in path over the defaults. A mix given in
+// the file replaces the default one rather than being merged into it.
+func loadScenario(path string) (Scenario, error) {
+	s := defaultScenario()
+	if path == "" {
+		return s, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return s, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	s.Currencies, s.Providers = nil, nil
+	if err := v.Unmarshal(&s); err != nil {
+		return s, fmt.Errorf("failed to decode scenario: %w", err)
+	}
+	s.countSet = v.IsSet("count")
+
+	// Viper lowercases map keys; currency codes are upper case.
+	if len(s.Currencies) == 0 {
+		s.Currencies = defaultCurrencies()
+	} else {
+		currencies := make(map[string]float64, len(s.Currencies))
+		for code, weight := range s.Currencies {
+			currencies[strings.ToUpper(code)] = weight
+		}
+		s.Currencies = currencies
+	}
+	if len(s.Providers) == 0 {
+		s.Providers = defaultProviders()
+	}
+
+	return s, nil
+}
+
+// setDefaults fills in the values that depend on what the file and flags
+// left unset: a run without a count or a duration sends defaultCount orders,
+// and a zero seed is replaced by a random one.
+func (s *Scenario) setDefaults() {
+	if !s.countSet && s.Duration == 0 {
+		s.Count = defaultCount
+	}
+	if s.Seed == 0 {
+		s.Seed = time.Now().UnixNano()
+	}
+}
+
+func (s Scenario) validate() error {
+	var errs []error
+	if s.Count < 0 || s.Duration < 0 || s.Rate < 0 {
+		errs = append(errs, errors.New("count, duration and rate must not be negative"))
+	}
+	if s.Concurrency < 1 {
+		errs = append(errs, errors.New("concurrency must be at least 1"))
+	}
+	if _, ok := formats[s.Format]; !ok {
+		errs = append(errs, fmt.Errorf("unknown format %q", s.Format))
+	}
+	if s.Items.Min < 1 || s.Items.Max < s.Items.Min {
+		errs = append(errs, fmt.Errorf("invalid items range %d-%d", s.Items.Min, s.Items.Max))
+	}
+	if err := validateWeights(s.Currencies); err != nil {
+		errs = append(errs, fmt.Errorf("currencies: %w", err))
+	}
+	if err := validateWeights(s.Providers); err != nil {
+		errs = append(errs, fmt.Errorf("providers: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func validateWeights(weights map[string]float64) error {
+	total := 0.0
+	for value, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("negative weight of %q", value)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return errors.New("at least one positive weight is required")
+	}
+	return nil
+}
+
+// parseItems parses an items range such as "1-5" or "3".
+func parseItems(s string) (ItemsRange, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	if !isRange {
+		to = from
+	}
+	lo, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return ItemsRange{}, fmt.Errorf("invalid items range %q", s)
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil {
+		return ItemsRange{}, fmt.Errorf("invalid items range %q", s)
+	}
+	return ItemsRange{Min: lo, Max: hi}, nil
+}
+
+// parseWeights parses a mix such as "USD=3,EUR=1". A value without a weight
+// counts as 1.
+func parseWeights(s string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		value, weight, hasWeight := strings.Cut(strings.TrimSpace(part), "=")
+		if value == "" {
+			return nil, fmt.Errorf("invalid mix %q", s)
+		}
+		w := 1.0
+		if hasWeight {
+			var err error
+			if w, err = strconv.ParseFloat(weight, 64); err != nil {
+				return nil, fmt.Errorf("invalid weight of %q: %w", value, err)
+			}
+		}
+		weights[value] = w
+	}
+	return weights, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// recorder collects the outcome and produce latency of every send.
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	failed    int
+	firstErr  error
+}
+
+func (r *recorder) record(latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.failed++
+		if r.firstErr == nil {
+			r.firstErr = err
+		}
+		return
+	}
+	r.latencies = append(r.latencies, latency)
+}
+
+type report struct {
+	Sent       int
+	Failed     int
+	FirstErr   error
+	Elapsed    time.Duration
+	Throughput float64
+
+	P50, P90, P99, Max time.Duration
+}
+
+func (r *recorder) report(elapsed time.Duration) report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sorted := slices.Clone(r.latencies)
+	slices.Sort(sorted)
+
+	rep := report{
+		Sent:     len(sorted),
+		Failed:   r.failed,
+		FirstErr: r.firstErr,
+		Elapsed:  elapsed,
+		P50:      percentile(sorted, 50),
+		P90:      percentile(sorted, 90),
+		P99:      percentile(sorted, 99),
+		Max:      percentile(sorted, 100),
+	}
+	if elapsed > 0 {
+		rep.Throughput = float64(rep.Sent) / elapsed.Seconds()
+	}
+	return rep
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,201 @@
+// Command replay reprocesses order records already in Kafka, e.g. ones
+// rejected by a validation bug that has since been fixed. It either rewinds
+// the consumer group of the service, which then consumes the records again
+// once restarted, or feeds the records through the ingestion path of the
+// service itself without joining any group. Ingestion is idempotent, so
+// replaying records that were already stored is harmless.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/bootstrap"
+	"order-service-wb/internal/cache"
+	"order-service-wb/internal/codec"
+	"order-service-wb/internal/ingest"
+	"order-service-wb/internal/kafka"
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/repository"
+	"order-service-wb/internal/schema"
+	"order-service-wb/internal/service"
+	"order-service-wb/pkg/config"
+)
+
+func main() {
+	group := flag.String("group", "", "rewind this consumer group instead of replaying the records directly")
+	partitions := flag.String("partitions", "", "partitions to replay, e.g. 0,2-4 (default all)")
+	offset := flag.Int64("offset", -1, "offset to start every partition at (default earliest)")
+	since := flag.String("since", "", "replay records produced at or after this RFC 3339 time")
+	until := flag.String("until", "", "replay records produced before this RFC 3339 time")
+	dryRun := flag.Bool("dry-run", false, "only decode and validate the records, or only print the offsets a rewind would commit")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	conf := config.NewConfig()
+
+	logger, err := logging.New(os.Stdout, conf.Log.Format, conf.Log.Level)
+	if err != nil {
+		log.Fatalf("failed to init logger: %v", err)
+	}
+
+	opts := kafka.ReplayOptions{Topic: conf.Kafka.Topic, Offset: *offset}
+	if opts.Partitions, err = parsePartitions(*partitions); err != nil {
+		bootstrap.Fatal(logger, "invalid -partitions", err)
+	}
+	if opts.Since, err = parseTime(*since); err != nil {
+		bootstrap.Fatal(logger, "invalid -since", err)
+	}
+	if opts.Until, err = parseTime(*until); err != nil {
+		bootstrap.Fatal(logger, "invalid -until", err)
+	}
+
+	brokers := []string{conf.Kafka.Broker}
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...))
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to create Kafka client", err)
+	}
+	defer client.Close()
+
+	ranges, err := kafka.PlanReplay(ctx, client, opts)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to plan replay", err)
+	}
+	total := int64(0)
+	for _, r := range ranges {
+		total += r.End - r.Start
+		logger.Info("replay range", "topic", r.Topic, "partition", r.Partition, "start", r.Start, "end", r.End)
+	}
+
+	if *group != "" {
+		if *dryRun {
+			logger.Info("dry run, group not rewound", "group", *group, "records", total)
+			return
+		}
+		if err = kafka.RewindGroup(ctx, client, *group, ranges); err != nil {
+			bootstrap.Fatal(logger, "failed to rewind consumer group", err)
+		}
+		logger.Info("consumer group rewound", "group", *group, "records", total)
+		return
+	}
+
+	ing, closeIngest, err := newIngester(conf, *dryRun, logger)
+	if err != nil {
+		bootstrap.Fatal(logger, "failed to init ingestion", err)
+	}
+	defer closeIngest()
+
+	handler := ing.Handle
+	if *dryRun {
+		handler = ing.Validate
+	}
+
+	retry := kafka.RetryPolicy{
+		MaxAttempts: conf.Kafka.Retry.MaxAttempts,
+		BaseDelay:   conf.Kafka.Retry.BaseDelay,
+		MaxDelay:    conf.Kafka.Retry.MaxDelay,
+		Jitter:      conf.Kafka.Retry.Jitter,
+		Retryable:   apperrors.IsTemporary,
+	}
+
+	stats, err := kafka.Replay(ctx, brokers, ranges, handler, retry, logger)
+	logger.Info("replay finished", "dry_run", *dryRun, "processed", stats.Processed, "failed", stats.Failed)
+	if err != nil {
+		bootstrap.Fatal(logger, "replay interrupted", err)
+	}
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// newIngester builds the ingestion path of the service. A dry run only
+// validates, so it does not connect to the database.
+func newIngester(conf *config.Config, dryRun bool, logger *slog.Logger) (*ingest.Ingester, func(), error) {
+	schemas, err := schema.OpenFileRegistry(conf.Schemas.Dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open schema registry: %w", err)
+	}
+	codecs, err := codec.Default(schemas)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init order codecs: %w", err)
+	}
+
+	ruleEngine, err := bootstrap.NewRuleEngine(conf.Validation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init business rules: %w", err)
+	}
+
+	c, err := cache.New(cache.Options{Policy: conf.Cache.Policy, Size: conf.Cache.Size, Shards: conf.Cache.Shards})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init cache: %w", err)
+	}
+
+	var db *sqlx.DB
+	closeDB := func() {}
+	if !dryRun {
+		if db, err = sqlx.Connect("postgres", conf.DbConfig.GetDSN()); err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		closeDB = func() { _ = db.Close() }
+	}
+
+	serv := service.NewOrderService(repository.NewOrderRepository(db, logger), c, ruleEngine, 0, logger)
+	return ingest.New(serv, codecs, logger), closeDB, nil
+}
+
+// parsePartitions parses a comma-separated list of partitions and ranges.
+func parsePartitions(s string) ([]int32, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var partitions []int32
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			to = from
+		}
+		first, err := strconv.ParseInt(from, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		last, err := strconv.ParseInt(to, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		if first < 0 || last < first {
+			return nil, fmt.Errorf("invalid partition range %q", part)
+		}
+		for p := first; p <= last; p++ {
+			partitions = append(partitions, int32(p))
+		}
+	}
+	return partitions, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("expected an RFC 3339 time such as 2025-07-16T12:00:00Z")
+	}
+	return t, nil
+}
@@ -0,0 +1,48 @@
+// Command schema registers a new version of a schema in the file registry
+// after checking it against the compatibility rule of its subject.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"order-service-wb/internal/schema"
+)
+
+func main() {
+	dir := flag.String("dir", "schemas", "schema registry directory")
+	subject := flag.String("subject", "order", "subject to register the schema under")
+	file := flag.String("file", "", "path to the Avro schema")
+	check := flag.Bool("check", false, "only check compatibility, do not register")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	definition, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("failed to read schema: %v", err)
+	}
+
+	registry, err := schema.OpenFileRegistry(*dir)
+	if err != nil {
+		log.Fatalf("failed to open schema registry: %v", err)
+	}
+
+	if *check {
+		if err = registry.Check(*subject, string(definition)); err != nil {
+			log.Fatalf("schema is not compatible: %v", err)
+		}
+		log.Printf("schema is compatible with the latest version of %q", *subject)
+		return
+	}
+
+	s, err := registry.Register(*subject, string(definition))
+	if err != nil {
+		log.Fatalf("failed to register schema: %v", err)
+	}
+	log.Printf("registered %q version %d with id %d", s.Subject, s.Version, s.ID)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errUnauthorized  = errors.New("a valid admin bearer token is required")
+	errAdminDisabled = errors.New("admin API is disabled: no admin token configured")
+)
+
+// adminMiddleware lets through requests carrying token as a bearer token. An
+// empty token disables the routes it guards altogether.
+func adminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			_ = c.Error(errAdminDisabled)
+			c.Abort()
+			return
+		}
+
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			_ = c.Error(errUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"order-service-wb/internal/service"
+	"order-service-wb/mocks"
+)
+
+func TestAdminRoutes_RequireToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		status        int
+	}{
+		{"valid token", "secret", "Bearer secret", http.StatusNoContent},
+		{"missing token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer guess", http.StatusUnauthorized},
+		{"not a bearer token", "secret", "secret", http.StatusUnauthorized},
+		{"admin API disabled", "", "Bearer ", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache := new(mocks.Cache)
+			mockCache.On("Delete", "uid").Return()
+			serv := service.NewOrderService(new(mocks.OrderRepository), mockCache, nil, 0, discardLogger)
+			r := NewHandler(serv, nil, tt.token, discardLogger).InitRouter()
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/cache/uid", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusNoContent {
+				mockCache.AssertCalled(t, "Delete", "uid")
+			} else {
+				mockCache.AssertNotCalled(t, "Delete", "uid")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/rules"
+)
+
+// problem is an RFC 7807 style error body. Code is stable and meant to be
+// matched by clients, Detail is human readable.
+type problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Code   string       `json:"code"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError describes a single failed validation rule. Field is the JSON path
+// of the offending value, e.g. "items[0].price".
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type errorKind struct {
+	target error
+	status int
+	code   string
+	title  string
+}
+
+var errorKinds = []errorKind{
+	{apperrors.ErrValidation, http.StatusBadRequest, "validation_failed", "Validation failed"},
+	{errPayloadTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large", "Payload too large"},
+	{errUnauthorized, http.StatusUnauthorized, "unauthorized", "Unauthorized"},
+	{errAdminDisabled, http.StatusForbidden, "forbidden", "Forbidden"},
+	{apperrors.ErrNotFound, http.StatusNotFound, "not_found", "Not found"},
+	{apperrors.ErrConflict, http.StatusConflict, "conflict", "Conflict"},
+	{apperrors.ErrTimeout, http.StatusGatewayTimeout, "timeout", "Timeout"},
+	{apperrors.ErrUnavailable, http.StatusServiceUnavailable, "unavailable", "Service unavailable"},
+}
+
+// errPayloadTooLarge is returned when a request body exceeds its size limit.
+var errPayloadTooLarge = errors.New("payload too large")
+
+var internalKind = errorKind{nil, http.StatusInternalServerError, "internal", "Internal server error"}
+
+// errorMiddleware renders the last error attached with c.Error as a problem
+// response. Details of server-side failures are not exposed to clients.
+func errorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		body := newProblem(c.Errors.Last().Err)
+
+		c.Header("Content-Type", "application/problem+json")
+		c.AbortWithStatusJSON(body.Status, body)
+	}
+}
+
+func newProblem(err error) *problem {
+	kind := classifyError(err)
+
+	body := &problem{
+		Type:   "about:blank",
+		Title:  kind.title,
+		Status: kind.status,
+		Code:   kind.code,
+	}
+	if kind.status < http.StatusInternalServerError {
+		body.Detail = err.Error()
+	}
+
+	var violationErr *rules.ViolationError
+	if errors.As(err, &violationErr) {
+		for _, v := range violationErr.Violations {
+			body.Errors = append(body.Errors, fieldError{
+				Field:   v.Field,
+				Rule:    v.Rule,
+				Message: v.Message,
+			})
+		}
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		body.Errors = make([]fieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			body.Errors = append(body.Errors, fieldError{
+				Field: fieldPath(fe.Namespace()),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+			})
+		}
+	}
+
+	return body
+}
+
+// fieldPath drops the root struct name from a validator namespace.
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+func classifyError(err error) errorKind {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.target) {
+			return kind
+		}
+	}
+	return internalKind
+}
+
+func badRequest(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", apperrors.ErrValidation, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/apperrors"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail bool
+	}{
+		{"not found", fmt.Errorf("order 1: %w", apperrors.ErrNotFound), http.StatusNotFound, "not_found", true},
+		{"validation", badRequest("limit must be a positive integer"), http.StatusBadRequest, "validation_failed", true},
+		{"conflict", fmt.Errorf("%w", apperrors.ErrConflict), http.StatusConflict, "conflict", true},
+		{"unavailable", fmt.Errorf("%w: connection reset", apperrors.ErrUnavailable), http.StatusServiceUnavailable, "unavailable", false},
+		{"timeout", fmt.Errorf("%w: deadline", apperrors.ErrTimeout), http.StatusGatewayTimeout, "timeout", false},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, "internal", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(errorMiddleware())
+			r.GET("/", func(c *gin.Context) {
+				_ = c.Error(tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+			var body problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Code)
+			assert.Equal(t, tt.wantStatus, body.Status)
+			assert.Equal(t, tt.wantDetail, body.Detail != "")
+		})
+	}
+}
@@ -1,49 +1,193 @@
 package api
 
 import (
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
+	"order-service-wb/internal/health"
+	"order-service-wb/internal/metrics"
+	"order-service-wb/internal/models"
 	"order-service-wb/internal/service"
+	"order-service-wb/internal/tracing"
 )
 
 type Handler struct {
-	serv service.OrderService
+	serv       service.OrderService
+	readiness  *health.Checker
+	adminToken string
+	logger     *slog.Logger
 }
 
-func NewHandler(serv service.OrderService) *Handler {
+// NewHandler creates the HTTP handler. readiness may be nil, in which case
+// /readyz always reports the service as ready. The /admin routes require
+// adminToken as a bearer token and are disabled if it is empty.
+func NewHandler(serv service.OrderService, readiness *health.Checker, adminToken string, logger *slog.Logger) *Handler {
 	return &Handler{
-		serv: serv,
+		serv:       serv,
+		readiness:  readiness,
+		adminToken: adminToken,
+		logger:     logger,
 	}
 }
 
 func (h *Handler) InitRouter() *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(
+		gin.Recovery(),
+		otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(traced)),
+		requestMiddleware(h.logger),
+		metrics.GinMiddleware(),
+		errorMiddleware(),
+	)
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.GET("/healthz", h.Liveness)
+	r.GET("/readyz", h.Readiness)
 
 	r.GET("/order/:uid", h.GetOrderByID)
+	r.GET("/orders", h.SearchOrders)
+	r.POST("/orders", h.CreateOrders)
+	r.PATCH("/order/:uid/status", h.ChangeOrderStatus)
+
+	admin := r.Group("/admin/cache", adminMiddleware(h.adminToken))
+	admin.GET("/stats", h.CacheStats)
+	admin.DELETE("/:uid", h.EvictFromCache)
+	admin.POST("/reload", h.ReloadCache)
+
 	r.Static("/web", "./web/static")
 
 	return r
 }
 
+// traced excludes probe and scrape requests from tracing.
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/metrics", "/healthz", "/readyz":
+		return false
+	}
+	return true
+}
+
 func (h *Handler) GetOrderByID(c *gin.Context) {
 	orderID := c.Param("uid")
 	if orderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order ID is required"})
+		_ = c.Error(badRequest("order ID is required"))
 		return
 	}
 
 	order, err := h.serv.GetOrderByID(c.Request.Context(), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get order"})
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+type changeStatusRequest struct {
+	Status models.OrderStatus `json:"status" binding:"required"`
+}
+
+func (h *Handler) ChangeOrderStatus(c *gin.Context) {
+	orderID := c.Param("uid")
+
+	var req changeStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(badRequest("status is required"))
 		return
 	}
 
-	if order == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	order, err := h.serv.ChangeOrderStatus(c.Request.Context(), orderID, req.Status)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, order)
 }
+
+func (h *Handler) SearchOrders(c *gin.Context) {
+	filter := models.OrderFilter{
+		CustomerID:      c.Query("customer_id"),
+		TrackNumber:     c.Query("track_number"),
+		DeliveryService: c.Query("delivery_service"),
+		PaymentProvider: c.Query("provider"),
+		Currency:        c.Query("currency"),
+		ItemBrand:       c.Query("brand"),
+	}
+
+	var err error
+	if v := c.Query("date_from"); v != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			_ = c.Error(badRequest("date_from must be an RFC 3339 timestamp"))
+			return
+		}
+	}
+	if v := c.Query("date_to"); v != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			_ = c.Error(badRequest("date_to must be an RFC 3339 timestamp"))
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			_ = c.Error(badRequest("limit must be a positive integer"))
+			return
+		}
+	}
+	if v := c.Query("cursor"); v != "" {
+		if filter.After, err = models.DecodeOrderCursor(v); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	page, err := h.serv.SearchOrders(c.Request.Context(), filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) CacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.serv.CacheStats())
+}
+
+func (h *Handler) EvictFromCache(c *gin.Context) {
+	h.serv.EvictFromCache(c.Param("uid"))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ReloadCache(c *gin.Context) {
+	if err := h.serv.ReloadCache(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.serv.CacheStats())
+}
+
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+func (h *Handler) Readiness(c *gin.Context) {
+	if h.readiness == nil {
+		c.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+		return
+	}
+
+	report := h.readiness.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.Up() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
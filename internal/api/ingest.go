@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"order-service-wb/internal/models"
+)
+
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+	// maxBulkOrders caps the number of orders accepted in one bulk request.
+	maxBulkOrders = 1000
+	// maxBodyBytes caps the size of an order creation request.
+	maxBodyBytes = 16 << 20
+)
+
+type bulkResult struct {
+	Index    int      `json:"index"`
+	OrderUID string   `json:"order_uid,omitempty"`
+	Status   string   `json:"status"`
+	Error    *problem `json:"error,omitempty"`
+}
+
+type bulkResponse struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []bulkResult `json:"results"`
+}
+
+// bulkItem is an order of a bulk request, or the reason it could not be
+// decoded.
+type bulkItem struct {
+	order models.Order
+	err   error
+}
+
+// CreateOrders accepts a single order object, a JSON array of orders or an
+// NDJSON stream (Content-Type: application/x-ndjson). A bulk request is read
+// in full before any order is created: a malformed or oversized request is
+// rejected as a whole, otherwise the answer is 200 with a result per order.
+func (h *Handler) CreateOrders(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+	body := bufio.NewReader(c.Request.Body)
+
+	if strings.HasPrefix(c.ContentType(), contentTypeNDJSON) {
+		h.createBulk(c, json.NewDecoder(body), false)
+		return
+	}
+
+	first, err := peekNonSpace(body)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			_ = c.Error(badRequest("request body is empty"))
+		} else {
+			_ = c.Error(payloadError(err, "failed to read request body"))
+		}
+		return
+	}
+
+	dec := json.NewDecoder(body)
+	if first != '[' {
+		h.createOne(c, dec)
+		return
+	}
+
+	if _, err = dec.Token(); err != nil {
+		_ = c.Error(payloadError(err, "invalid JSON array"))
+		return
+	}
+	h.createBulk(c, dec, true)
+}
+
+func (h *Handler) createOne(c *gin.Context, dec *json.Decoder) {
+	var order models.Order
+	if err := dec.Decode(&order); err != nil {
+		_ = c.Error(payloadError(err, "invalid order payload"))
+		return
+	}
+
+	if err := h.serv.CreateOrder(c.Request.Context(), &order); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &order)
+}
+
+func (h *Handler) createBulk(c *gin.Context, dec *json.Decoder, array bool) {
+	items, err := decodeBulk(dec, array)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := bulkResponse{Results: make([]bulkResult, 0, len(items))}
+	for index, item := range items {
+		if item.err != nil {
+			resp.add(index, "", item.err)
+			continue
+		}
+		resp.add(index, item.order.OrderUID, h.serv.CreateOrder(c.Request.Context(), &item.order))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// decodeBulk reads every order of a bulk request. Orders that are well-formed
+// JSON but do not fit the model are kept as failed items; anything that makes
+// the rest of the request unreadable fails the whole request.
+func decodeBulk(dec *json.Decoder, array bool) ([]bulkItem, error) {
+	var items []bulkItem
+	for index := 0; dec.More(); index++ {
+		if index >= maxBulkOrders {
+			return nil, badRequest("bulk request exceeds %d orders", maxBulkOrders)
+		}
+
+		var item bulkItem
+		if err := dec.Decode(&item.order); err != nil {
+			var syntaxErr *json.SyntaxError
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &syntaxErr) || errors.As(err, &maxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+				// The stream cannot be resynchronised after a syntax error.
+				return nil, payloadError(err, "invalid order payload at index %d", index)
+			}
+			item.err = badRequest("invalid order payload: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if array {
+		if tok, err := dec.Token(); err != nil || tok != json.Delim(']') {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, payloadError(err, "invalid JSON array")
+			}
+			return nil, badRequest("invalid JSON array: missing closing bracket")
+		}
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return nil, payloadError(err, "invalid request body")
+		}
+		return nil, badRequest("unexpected data after the orders")
+	}
+
+	return items, nil
+}
+
+// payloadError describes a failure to read or parse the request body.
+func payloadError(err error, format string, args ...any) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return fmt.Errorf("%w: request body exceeds %d bytes", errPayloadTooLarge, maxErr.Limit)
+	}
+	return badRequest("%s: %v", fmt.Sprintf(format, args...), err)
+}
+
+func (r *bulkResponse) add(index int, orderUID string, err error) {
+	result := bulkResult{Index: index, OrderUID: orderUID, Status: "created"}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = newProblem(err)
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	r.Results = append(r.Results, result)
+}
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsRune([]byte(" \t\r\n"), rune(b)) {
+			return b, r.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/service"
+	"order-service-wb/mocks"
+)
+
+const validOrderJSON = `{"order_uid":"%[1]s","track_number":"WBTRACK","entry":"WBIL",
+"delivery":{"name":"Test User","phone":"+1234567890","zip":"123456","city":"TestCity",
+"address":"123 Test St","region":"TestRegion","email":"test@example.com"},
+"payment":{"transaction":"%[1]s","request_id":"1","currency":"USD","provider":"wbpay",
+"amount":1000,"payment_dt":1637907727,"bank":"alpha","delivery_cost":500,"goods_total":500,"custom_fee":0},
+"items":[{"chrt_id":1,"track_number":"WBTRACK","price":500,"rid":"r1","name":"Product",
+"sale":0,"size":"L","total_price":500,"nm_id":1,"brand":"Brand","status":202}],
+"locale":"en","customer_id":"testuser","date_created":"2025-07-01T10:00:00Z"}`
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func validOrder(uid string) string {
+	return strings.ReplaceAll(fmt.Sprintf(validOrderJSON, uid), "\n", "")
+}
+
+func newIngestRouter(t *testing.T) (*gin.Engine, *mocks.OrderRepository) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+	mockCache.On("Set", mock.Anything, mock.Anything).Return()
+
+	h := NewHandler(service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger), nil, "", discardLogger)
+	r := gin.New()
+	r.Use(errorMiddleware())
+	r.POST("/orders", h.CreateOrders)
+
+	return r, mockRepo
+}
+
+func TestCreateOrders_ValidationErrors(t *testing.T) {
+	r, mockRepo := newIngestRouter(t)
+
+	body := strings.Replace(validOrder("1"), `"price":500`, `"price":-1`, 1)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, fieldError{Field: "items[0].price", Rule: "gte", Param: "0"}, resp.Errors[0])
+
+	mockRepo.AssertNotCalled(t, "CreateOrder")
+}
+
+func TestCreateOrders_NDJSON(t *testing.T) {
+	r, mockRepo := newIngestRouter(t)
+	mockRepo.On("CreateOrder", mock.Anything, mock.Anything).Return(nil)
+
+	body := validOrder("1") + "\n" + `{"order_uid":"2"}` + "\n" + validOrder("3") + "\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentTypeNDJSON)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp bulkResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, "failed", resp.Results[1].Status)
+	assert.Equal(t, "validation_failed", resp.Results[1].Error.Code)
+	assert.NotEmpty(t, resp.Results[1].Error.Errors)
+}
+
+func TestCreateOrders_RejectedBulkStoresNothing(t *testing.T) {
+	tooMany := make([]string, maxBulkOrders+1)
+	for i := range tooMany {
+		tooMany[i] = validOrder(fmt.Sprint(i))
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"too many orders", "[" + strings.Join(tooMany, ",") + "]", http.StatusBadRequest},
+		{"syntax error", "[" + validOrder("1") + `,{"order_uid":}]`, http.StatusBadRequest},
+		{"missing closing bracket", "[" + validOrder("1") + "," + validOrder("2"), http.StatusBadRequest},
+		{"trailing data", "[" + validOrder("1") + "]" + validOrder("2"), http.StatusBadRequest},
+		{"body too large", "[" + validOrder(strings.Repeat("x", maxBodyBytes)) + "]", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mockRepo := newIngestRouter(t)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.status, w.Code)
+			mockRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateOrders_JSONArray(t *testing.T) {
+	r, mockRepo := newIngestRouter(t)
+	mockRepo.On("CreateOrder", mock.Anything, mock.Anything).Return(nil)
+
+	body := "[" + validOrder("1") + `,{"order_uid":1},` + validOrder("3") + "]\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp bulkResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	mockRepo.AssertNumberOfCalls(t, "CreateOrder", 2)
+}
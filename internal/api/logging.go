@@ -0,0 +1,53 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"order-service-wb/internal/logging"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestMiddleware assigns every request an ID, taken from the X-Request-ID
+// header when the client sends one, stores it in the request context for the
+// service and repository logs, echoes it back and logs the finished request.
+func requestMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Header(requestIDHeader, requestID)
+
+		ctx := logging.With(c.Request.Context(), logging.KeyRequestID, requestID)
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, logging.Err(c.Errors.Last().Err))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(ctx, level, "http request", attrs...)
+	}
+}
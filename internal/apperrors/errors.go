@@ -0,0 +1,19 @@
+// Package apperrors defines the domain error kinds shared by every layer.
+// Lower layers wrap their errors with one of the sentinels so that callers
+// can react with errors.Is without knowing where the error came from.
+package apperrors
+
+import "errors"
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("unavailable")
+	ErrTimeout     = errors.New("timeout")
+)
+
+// IsTemporary reports whether err may go away if the operation is retried.
+func IsTemporary(err error) bool {
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout)
+}
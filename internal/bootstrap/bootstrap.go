@@ -0,0 +1,27 @@
+// Package bootstrap holds the start-up helpers shared by the commands, so
+// that the service and its tools build their components the same way.
+package bootstrap
+
+import (
+	"log/slog"
+	"os"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/rules"
+	"order-service-wb/pkg/config"
+)
+
+// Fatal logs err and exits with status 1.
+func Fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, logging.Err(err))
+	os.Exit(1)
+}
+
+// NewRuleEngine builds the business rule engine from the validation config.
+func NewRuleEngine(conf config.ValidationConfig) (*rules.Engine, error) {
+	settings := make(map[string]rules.Setting, len(conf.Rules))
+	for name, rule := range conf.Rules {
+		settings[name] = rules.Setting{Enabled: rule.Enabled, Mode: rules.Mode(rule.Mode)}
+	}
+	return rules.NewEngine(settings)
+}
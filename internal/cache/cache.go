@@ -1,14 +1,26 @@
 package cache
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"order-service-wb/internal/models"
 )
 
+const (
+	PolicyFIFO = "fifo"
+	PolicyLRU  = "lru"
+)
+
 type Cache interface {
 	Set(id string, order models.Order)
 	Get(id string) (models.Order, bool)
+	Delete(id string)
+	Len() int
+	Purge()
+	Stats() Stats
 }
 
 type MyCache struct {
@@ -16,8 +28,51 @@ type MyCache struct {
 	store map[string]models.Order
 	order []string
 	size  int
+	stats counters
+}
+
+type Options struct {
+	Policy string
+	Size   int
+	// Shards splits the cache into independently locked segments when > 1.
+	Shards int
+	// TTL and MaxBytes are only supported by the LRU policy; New rejects
+	// them with FIFO.
+	TTL      time.Duration
+	MaxBytes int64
+}
+
+func New(opts Options) (Cache, error) {
+	if opts.Size <= 0 {
+		return nil, fmt.Errorf("cache size must be positive, got %d", opts.Size)
+	}
+
+	var newSegment func(size int) Cache
+	shards := min(max(opts.Shards, 1), opts.Size)
+
+	switch opts.Policy {
+	case "", PolicyFIFO:
+		if opts.TTL != 0 || opts.MaxBytes != 0 {
+			return nil, errors.New("cache ttl and max_bytes require the lru policy")
+		}
+		newSegment = NewCache
+	case PolicyLRU:
+		maxBytes := opts.MaxBytes / int64(shards)
+		newSegment = func(size int) Cache {
+			return NewLRUCache(size, opts.TTL, maxBytes)
+		}
+	default:
+		return nil, fmt.Errorf("unknown cache policy %q", opts.Policy)
+	}
+
+	if shards == 1 {
+		return newSegment(opts.Size), nil
+	}
+	return NewShardedCache(shards, opts.Size, newSegment)
 }
 
+// NewCache creates a FIFO cache of up to size orders. A size of zero or less
+// disables it: Set stores nothing.
 func NewCache(size int) Cache {
 	return &MyCache{
 		store: make(map[string]models.Order),
@@ -30,6 +85,9 @@ func NewCache(size int) Cache {
 func (c *MyCache) Set(id string, order models.Order) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
 	if _, ok := c.store[id]; ok {
 		c.store[id] = order
 		return
@@ -39,6 +97,7 @@ func (c *MyCache) Set(id string, order models.Order) {
 		old := c.order[0]
 		delete(c.store, old)
 		c.order = c.order[1:]
+		c.stats.evictions.Add(1)
 	}
 
 	c.store[id] = order
@@ -49,5 +108,39 @@ func (c *MyCache) Get(id string) (models.Order, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	val, ok := c.store[id]
+	c.stats.lookup(ok)
 	return val, ok
 }
+
+func (c *MyCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.store[id]; !ok {
+		return
+	}
+
+	delete(c.store, id)
+	for i, key := range c.order {
+		if key == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *MyCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.store)
+}
+
+func (c *MyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = make(map[string]models.Order)
+	c.order = make([]string, 0, c.size)
+}
+
+func (c *MyCache) Stats() Stats {
+	return c.stats.snapshot(c.Len())
+}
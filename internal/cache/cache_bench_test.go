@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"order-service-wb/internal/cache"
+	"order-service-wb/internal/models"
+)
+
+const (
+	benchCacheSize = 10_000
+	benchKeys      = 20_000
+	// benchWritePercent is the share of Set calls in the mixed workload.
+	benchWritePercent = 10
+)
+
+func benchmarkMixed(b *testing.B, c cache.Cache) {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = "order-" + strconv.Itoa(i)
+	}
+	for _, key := range keys[:benchCacheSize] {
+		c.Set(key, models.Order{OrderUID: key})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			key := keys[rnd.Intn(len(keys))]
+			if rnd.Intn(100) < benchWritePercent {
+				c.Set(key, models.Order{OrderUID: key})
+			} else {
+				c.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkMyCache_Mixed(b *testing.B) {
+	benchmarkMixed(b, cache.NewCache(benchCacheSize))
+}
+
+func BenchmarkLRUCache_Mixed(b *testing.B) {
+	benchmarkMixed(b, cache.NewLRUCache(benchCacheSize, 0, 0))
+}
+
+func BenchmarkShardedCache_Mixed(b *testing.B) {
+	for _, shards := range []int{4, 16, 64} {
+		b.Run("fifo/"+strconv.Itoa(shards), func(b *testing.B) {
+			benchmarkMixed(b, newShardedCache(b, shards, cache.NewCache))
+		})
+		b.Run("lru/"+strconv.Itoa(shards), func(b *testing.B) {
+			benchmarkMixed(b, newShardedCache(b, shards, func(size int) cache.Cache {
+				return cache.NewLRUCache(size, 0, 0)
+			}))
+		})
+	}
+}
+
+func newShardedCache(b *testing.B, shards int, newShard func(size int) cache.Cache) cache.Cache {
+	c, err := cache.NewShardedCache(shards, benchCacheSize, newShard)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
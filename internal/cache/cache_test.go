@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"order-service-wb/internal/models"
+)
+
+func TestNew_RejectsLRUOptionsWithFIFO(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []Options{
+		{Policy: PolicyFIFO, Size: 10, TTL: time.Minute},
+		{Size: 10, MaxBytes: 1 << 20},
+		{Policy: PolicyFIFO, Size: 10, Shards: 4, TTL: time.Minute},
+	} {
+		_, err := New(opts)
+		assert.Error(t, err, "%+v", opts)
+	}
+
+	_, err := New(Options{Policy: PolicyLRU, Size: 10, Shards: 4, TTL: time.Minute, MaxBytes: 1 << 20})
+	assert.NoError(t, err)
+}
+
+func TestMyCache_ZeroSizeStoresNothing(t *testing.T) {
+	t.Parallel()
+
+	c := NewCache(0)
+
+	assert.NotPanics(t, func() { c.Set("1", models.Order{OrderUID: "1"}) })
+	_, ok := c.Get("1")
+	assert.False(t, ok)
+	assert.Zero(t, c.Len())
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"order-service-wb/internal/models"
+)
+
+// LRUCache evicts the least recently used order once the entry count or the
+// estimated byte size exceeds its limits. Entries optionally expire after ttl.
+// A zero size, ttl or maxBytes disables the corresponding bound.
+type LRUCache struct {
+	mu       sync.Mutex
+	items    map[string]*list.Element
+	ll       *list.List
+	size     int
+	ttl      time.Duration
+	maxBytes int64
+	bytes    int64
+	now      func() time.Time
+	stats    counters
+}
+
+type lruEntry struct {
+	id        string
+	order     models.Order
+	bytes     int64
+	expiresAt time.Time
+}
+
+func NewLRUCache(size int, ttl time.Duration, maxBytes int64) Cache {
+	return &LRUCache{
+		items:    make(map[string]*list.Element),
+		ll:       list.New(),
+		size:     size,
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		now:      time.Now,
+	}
+}
+
+func (c *LRUCache) Set(id string, order models.Order) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry{
+		id:    id,
+		order: order,
+		bytes: estimateSize(&order),
+	}
+	if c.ttl > 0 {
+		entry.expiresAt = c.now().Add(c.ttl)
+	}
+
+	if el, ok := c.items[id]; ok {
+		c.bytes -= el.Value.(*lruEntry).bytes
+		el.Value = entry
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[id] = c.ll.PushFront(entry)
+	}
+	c.bytes += entry.bytes
+
+	for c.overLimit() {
+		c.removeElement(c.ll.Back())
+		c.stats.evictions.Add(1)
+	}
+}
+
+func (c *LRUCache) Get(id string) (models.Order, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[id]
+	if !ok {
+		c.stats.lookup(false)
+		return models.Order{}, false
+	}
+
+	entry := el.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.removeElement(el)
+		c.stats.evictions.Add(1)
+		c.stats.lookup(false)
+		return models.Order{}, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.stats.lookup(true)
+	return entry.order, true
+}
+
+func (c *LRUCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[id]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRUCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]*list.Element)
+	c.ll.Init()
+	c.bytes = 0
+}
+
+func (c *LRUCache) Stats() Stats {
+	return c.stats.snapshot(c.Len())
+}
+
+func (c *LRUCache) overLimit() bool {
+	if c.ll.Len() == 0 {
+		return false
+	}
+	return (c.size > 0 && c.ll.Len() > c.size) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+func (c *LRUCache) removeElement(el *list.Element) {
+	entry := c.ll.Remove(el).(*lruEntry)
+	delete(c.items, entry.id)
+	c.bytes -= entry.bytes
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"order-service-wb/internal/models"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	c := NewLRUCache(2, 0, 0)
+
+	c.Set("1", models.Order{OrderUID: "1"})
+	c.Set("2", models.Order{OrderUID: "2"})
+
+	_, ok := c.Get("1")
+	assert.True(t, ok)
+
+	c.Set("3", models.Order{OrderUID: "3"})
+
+	_, ok = c.Get("2")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	_, ok = c.Get("1")
+	assert.True(t, ok)
+	_, ok = c.Get("3")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_ExpiresEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := NewLRUCache(10, time.Minute, 0).(*LRUCache)
+	c.now = func() time.Time { return now }
+
+	c.Set("1", models.Order{OrderUID: "1"})
+
+	_, ok := c.Get("1")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+
+	_, ok = c.Get("1")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.ll.Len())
+}
+
+func TestLRUCache_RespectsByteBudget(t *testing.T) {
+	t.Parallel()
+
+	order := models.Order{OrderUID: "1", Items: make([]models.Item, 4)}
+	budget := 2 * estimateSize(&order)
+
+	c := NewLRUCache(0, 0, budget).(*LRUCache)
+
+	for _, id := range []string{"1", "2", "3"} {
+		order.OrderUID = id
+		c.Set(id, order)
+	}
+
+	assert.LessOrEqual(t, c.bytes, budget)
+	_, ok := c.Get("1")
+	assert.False(t, ok)
+	_, ok = c.Get("3")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_Stats(t *testing.T) {
+	t.Parallel()
+
+	c := NewLRUCache(1, 0, 0)
+
+	c.Set("1", models.Order{OrderUID: "1"})
+	c.Get("1")
+	c.Get("2")
+	c.Set("2", models.Order{OrderUID: "2"})
+	c.Delete("2")
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 1, Evictions: 1, Size: 0}, c.Stats())
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"fmt"
+
+	"order-service-wb/internal/models"
+)
+
+// ShardedCache spreads orders across independently locked segments by the
+// hash of their order_uid so that writers to different segments do not
+// contend. Each segment enforces its own share of the capacity.
+type ShardedCache struct {
+	shards []Cache
+}
+
+// NewShardedCache splits size across the shards so that their capacities add
+// up to size exactly. There are never more shards than entries, as a shard
+// needs room for at least one.
+func NewShardedCache(shards, size int, newShard func(size int) Cache) (Cache, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("cache size must be positive, got %d", size)
+	}
+	shards = min(max(shards, 1), size)
+
+	c := &ShardedCache{shards: make([]Cache, shards)}
+	for i := range c.shards {
+		perShard := size / shards
+		if i < size%shards {
+			perShard++
+		}
+		c.shards[i] = newShard(perShard)
+	}
+	return c, nil
+}
+
+func (c *ShardedCache) Set(id string, order models.Order) {
+	c.shard(id).Set(id, order)
+}
+
+func (c *ShardedCache) Get(id string) (models.Order, bool) {
+	return c.shard(id).Get(id)
+}
+
+func (c *ShardedCache) Delete(id string) {
+	c.shard(id).Delete(id)
+}
+
+func (c *ShardedCache) Len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+func (c *ShardedCache) Purge() {
+	for _, shard := range c.shards {
+		shard.Purge()
+	}
+}
+
+func (c *ShardedCache) Stats() Stats {
+	var total Stats
+	for _, shard := range c.shards {
+		s := shard.Stats()
+		total.Hits += s.Hits
+		total.Misses += s.Misses
+		total.Evictions += s.Evictions
+		total.Size += s.Size
+	}
+	return total
+}
+
+func (c *ShardedCache) shard(id string) Cache {
+	return c.shards[fnv32a(id)%uint32(len(c.shards))]
+}
+
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= prime32
+	}
+	return h
+}
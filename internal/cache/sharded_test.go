@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/models"
+)
+
+func TestNewShardedCache_CapacityMatchesSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		shards, size int
+		perShard     []int
+	}{
+		{shards: 4, size: 10, perShard: []int{3, 3, 2, 2}},
+		{shards: 4, size: 8, perShard: []int{2, 2, 2, 2}},
+		{shards: 8, size: 3, perShard: []int{1, 1, 1}},
+		{shards: 0, size: 5, perShard: []int{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.shards)+"x"+strconv.Itoa(tt.size), func(t *testing.T) {
+			var sizes []int
+			c, err := NewShardedCache(tt.shards, tt.size, func(size int) Cache {
+				sizes = append(sizes, size)
+				return NewCache(size)
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.perShard, sizes)
+
+			for i := range 10 * tt.size {
+				id := strconv.Itoa(i)
+				c.Set(id, models.Order{OrderUID: id})
+			}
+			assert.Equal(t, tt.size, c.Len())
+		})
+	}
+}
+
+func TestNewShardedCache_RejectsNonPositiveSize(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewShardedCache(4, 0, NewCache)
+	assert.Error(t, err)
+
+	_, err = New(Options{Policy: PolicyLRU, Size: -1, Shards: 4})
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"unsafe"
+
+	"order-service-wb/internal/models"
+)
+
+// estimateSize approximates the memory held by an order: the struct itself,
+// its items and the bytes of every string it references.
+func estimateSize(order *models.Order) int64 {
+	size := int64(unsafe.Sizeof(*order)) +
+		int64(len(order.Items))*int64(unsafe.Sizeof(models.Item{}))
+
+	size += int64(len(order.OrderUID) + len(order.TrackNumber) + len(order.Entry) +
+		len(order.Locale) + len(order.InternalSig) + len(order.CustomerID) +
+		len(order.DeliverySrv) + len(order.ShardKey) + len(order.OofShard) +
+		len(order.Status))
+
+	d := &order.Delivery
+	size += int64(len(d.Name) + len(d.Phone) + len(d.Zip) + len(d.City) +
+		len(d.Addr) + len(d.Region) + len(d.Email))
+
+	p := &order.Payment
+	size += int64(len(p.Transaction) + len(p.RequestID) + len(p.Currency) +
+		len(p.Provider) + len(p.Bank))
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		size += int64(len(item.TrackNumber) + len(item.Rid) + len(item.Name) +
+			len(item.Size) + len(item.Brand))
+	}
+
+	return size
+}
@@ -0,0 +1,82 @@
+package codec
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/schema"
+)
+
+// avroAPI maps record fields by the json tags of the models, which already
+// match the field names of the schema.
+var avroAPI = avro.Config{TagKey: "json"}.Freeze()
+
+// Avro encodes orders as bare binary datums, without an object container
+// header, using the latest registered version of the subject. Data written
+// with an earlier version is resolved into the latest one on decode.
+type Avro struct {
+	registry schema.Registry
+	subject  string
+	latest   schema.Schema
+
+	resolved sync.Map // writer schema id -> avro.Schema
+}
+
+func NewAvro(registry schema.Registry, subject string) (*Avro, error) {
+	latest, err := registry.Latest(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load avro schema: %w", err)
+	}
+	return &Avro{registry: registry, subject: subject, latest: latest}, nil
+}
+
+func (*Avro) ContentType() string {
+	return ContentTypeAvro
+}
+
+func (a *Avro) SchemaID() int {
+	return a.latest.ID
+}
+
+func (a *Avro) Marshal(order *models.Order) ([]byte, error) {
+	return avroAPI.Marshal(a.latest.Avro, order)
+}
+
+func (a *Avro) Unmarshal(data []byte, order *models.Order) error {
+	return avroAPI.Unmarshal(a.latest.Avro, data, order)
+}
+
+func (a *Avro) UnmarshalSchema(data []byte, schemaID int, order *models.Order) error {
+	s, err := a.readerFor(schemaID)
+	if err != nil {
+		return err
+	}
+	return avroAPI.Unmarshal(s, data, order)
+}
+
+func (a *Avro) readerFor(schemaID int) (avro.Schema, error) {
+	if schemaID == a.latest.ID {
+		return a.latest.Avro, nil
+	}
+	if s, ok := a.resolved.Load(schemaID); ok {
+		return s.(avro.Schema), nil
+	}
+
+	writer, err := a.registry.ByID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	if writer.Subject != a.subject {
+		return nil, fmt.Errorf("%w: id %d belongs to subject %q", schema.ErrNotFound, schemaID, writer.Subject)
+	}
+
+	resolved, err := schema.Resolve(a.latest.Avro, writer.Avro)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", schemaID, err)
+	}
+	a.resolved.Store(schemaID, resolved)
+	return resolved, nil
+}
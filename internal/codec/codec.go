@@ -0,0 +1,117 @@
+// Package codec decodes and encodes orders in the wire formats accepted on the
+// order topic. The format of a record is selected by its content type.
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"slices"
+	"strconv"
+
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/schema"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+// OrderSubject is the schema registry subject of the order payload.
+const OrderSubject = "order"
+
+var ErrUnknownContentType = errors.New("unknown content type")
+
+type Codec interface {
+	ContentType() string
+	Marshal(order *models.Order) ([]byte, error)
+	Unmarshal(data []byte, order *models.Order) error
+}
+
+// SchemaCodec is a Codec whose payloads are written with a registered schema.
+// Producers send the id of that schema alongside the payload so that
+// consumers on a later version can still decode it.
+type SchemaCodec interface {
+	Codec
+	// SchemaID is the id of the schema Marshal writes with.
+	SchemaID() int
+	UnmarshalSchema(data []byte, schemaID int, order *models.Order) error
+}
+
+// Decode unmarshals data with c. A non-empty schemaID selects the writer
+// schema of a SchemaCodec; other codecs do not accept one.
+func Decode(c Codec, data []byte, schemaID string, order *models.Order) error {
+	if schemaID == "" {
+		return c.Unmarshal(data, order)
+	}
+
+	sc, ok := c.(SchemaCodec)
+	if !ok {
+		return fmt.Errorf("%w: %s payloads have no schema id", schema.ErrNotFound, c.ContentType())
+	}
+	id, err := strconv.Atoi(schemaID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid id %q", schema.ErrNotFound, schemaID)
+	}
+	return sc.UnmarshalSchema(data, id, order)
+}
+
+// Registry maps content types to codecs. An empty content type selects JSON,
+// which is what producers sent before formats were negotiated.
+type Registry struct {
+	codecs map[string]Codec
+}
+
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{codecs: make(map[string]Codec, len(codecs))}
+	for _, c := range codecs {
+		r.codecs[c.ContentType()] = c
+	}
+	return r
+}
+
+// Default returns a registry with the JSON, Protobuf and Avro codecs. The JSON
+// and Avro codecs use the OrderSubject schemas of schemas; Protobuf payloads
+// are versioned by the field numbers of schemas/order.proto instead.
+func Default(schemas schema.Registry) (*Registry, error) {
+	jsonCodec, err := NewJSON(schemas, OrderSubject)
+	if err != nil {
+		return nil, err
+	}
+	avro, err := NewAvro(schemas, OrderSubject)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(jsonCodec, Protobuf{}, avro), nil
+}
+
+// Lookup returns the codec for contentType. Media type parameters such as
+// charset are ignored.
+func (r *Registry) Lookup(contentType string) (Codec, error) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrUnknownContentType, contentType, err)
+	}
+
+	c, ok := r.codecs[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownContentType, contentType)
+	}
+	return c, nil
+}
+
+// ContentTypes lists the registered content types in sorted order.
+func (r *Registry) ContentTypes() []string {
+	types := make([]string, 0, len(r.codecs))
+	for contentType := range r.codecs {
+		types = append(types, contentType)
+	}
+	slices.Sort(types)
+	return types
+}
@@ -0,0 +1,193 @@
+package codec_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/codec"
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/schema"
+)
+
+const schemasDir = "../../schemas"
+
+func defaultRegistry(t *testing.T) *codec.Registry {
+	schemas, err := schema.OpenFileRegistry(schemasDir)
+	require.NoError(t, err)
+	registry, err := codec.Default(schemas)
+	require.NoError(t, err)
+	return registry
+}
+
+func testOrder() models.Order {
+	return models.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: models.Delivery{
+			Name:   "Test Testov",
+			Phone:  "+9720000000",
+			Zip:    "2639809",
+			City:   "Kiryat Mozkin",
+			Addr:   "Ploshad Mira 15",
+			Region: "Kraiot",
+			Email:  "test@gmail.com",
+		},
+		Payment: models.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			RequestID:    "1",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDT:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []models.Item{
+			{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "ab4219087a764ae0btest", Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202},
+			{ChrtID: 1, TrackNumber: "WBILMTESTTRACK", Price: 1, Rid: "second", Name: "Brush", Size: "S", TotalPrice: 1, NmID: 2, Brand: "Other"},
+		},
+		Locale:      "en",
+		CustomerID:  "test",
+		DeliverySrv: "meest",
+		ShardKey:    "9",
+		SmID:        99,
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 123456000, time.UTC),
+		OofShard:    "1",
+		Version:     3,
+		Status:      models.StatusPaid,
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	registry := defaultRegistry(t)
+
+	for _, contentType := range registry.ContentTypes() {
+		t.Run(contentType, func(t *testing.T) {
+			c, err := registry.Lookup(contentType)
+			require.NoError(t, err)
+			assert.Equal(t, contentType, c.ContentType())
+
+			order := testOrder()
+			data, err := c.Marshal(&order)
+			require.NoError(t, err)
+
+			var decoded models.Order
+			require.NoError(t, c.Unmarshal(data, &decoded))
+			assert.Equal(t, order, decoded)
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	registry := defaultRegistry(t)
+
+	c, err := registry.Lookup("")
+	require.NoError(t, err)
+	assert.Equal(t, codec.ContentTypeJSON, c.ContentType(), "missing content type defaults to JSON")
+
+	c, err = registry.Lookup("application/json; charset=utf-8")
+	require.NoError(t, err)
+	assert.Equal(t, codec.ContentTypeJSON, c.ContentType())
+
+	_, err = registry.Lookup("application/xml")
+	assert.ErrorIs(t, err, codec.ErrUnknownContentType)
+
+	_, err = registry.Lookup("not a media type;")
+	assert.ErrorIs(t, err, codec.ErrUnknownContentType)
+}
+
+func TestProtobuf_RejectsMalformedInput(t *testing.T) {
+	var order models.Order
+	assert.Error(t, codec.Protobuf{}.Unmarshal([]byte{0x0a, 0x05, 'a'}, &order), "truncated string")
+	assert.Error(t, codec.Protobuf{}.Unmarshal([]byte{0x08, 0x01}, &order), "varint where a string is expected")
+}
+
+func TestAvro_DecodesEarlierSchemaVersion(t *testing.T) {
+	current, err := os.ReadFile(schemasDir + "/order/v1.avsc")
+	require.NoError(t, err)
+	// The earlier version predates the status field.
+	statusField := `    {"name": "status", "type": "string", "default": ""}`
+	require.Contains(t, string(current), statusField)
+	earlier := strings.Replace(string(current), ",\n"+statusField, "", 1)
+
+	schemas, err := schema.OpenFileRegistry(t.TempDir())
+	require.NoError(t, err)
+	v1, err := schemas.Register(codec.OrderSubject, earlier)
+	require.NoError(t, err)
+
+	old, err := codec.NewAvro(schemas, codec.OrderSubject)
+	require.NoError(t, err)
+	order := testOrder()
+	data, err := old.Marshal(&order)
+	require.NoError(t, err)
+
+	v2, err := schemas.Register(codec.OrderSubject, string(current))
+	require.NoError(t, err)
+	require.NotEqual(t, v1.ID, v2.ID)
+
+	avro, err := codec.NewAvro(schemas, codec.OrderSubject)
+	require.NoError(t, err)
+	assert.Equal(t, v2.ID, avro.SchemaID())
+
+	var decoded models.Order
+	require.NoError(t, codec.Decode(avro, data, "1", &decoded))
+	want := testOrder()
+	want.Status = ""
+	assert.Equal(t, want, decoded)
+
+	assert.ErrorIs(t, codec.Decode(avro, data, "7", &decoded), schema.ErrNotFound)
+	assert.ErrorIs(t, codec.Decode(codec.JSON{}, data, "1", &decoded), schema.ErrNotFound)
+}
+
+func TestJSON_RejectsFieldsOutsideSchema(t *testing.T) {
+	current, err := os.ReadFile(schemasDir + "/order/v1.avsc")
+	require.NoError(t, err)
+	// The earlier version had a field the current one dropped.
+	statusField := `    {"name": "status", "type": "string", "default": ""}`
+	earlier := strings.Replace(string(current), statusField,
+		statusField+",\n"+`    {"name": "legacy_note", "type": "string", "default": ""}`, 1)
+
+	schemas, err := schema.OpenFileRegistry(t.TempDir())
+	require.NoError(t, err)
+	v1, err := schemas.Register(codec.OrderSubject, earlier)
+	require.NoError(t, err)
+	v2, err := schemas.Register(codec.OrderSubject, string(current))
+	require.NoError(t, err)
+
+	jsonCodec, err := codec.NewJSON(schemas, codec.OrderSubject)
+	require.NoError(t, err)
+	assert.Equal(t, v2.ID, jsonCodec.SchemaID())
+
+	order := testOrder()
+	data, err := jsonCodec.Marshal(&order)
+	require.NoError(t, err)
+	withField := func(field string) []byte {
+		return []byte(strings.Replace(string(data), `"items":[{`, `"items":[{`+field+`,`, 1))
+	}
+	legacy := []byte(strings.Replace(string(data), "{", `{"legacy_note":"x",`, 1))
+
+	var decoded models.Order
+	require.NoError(t, codec.Decode(jsonCodec, data, "", &decoded))
+	assert.Equal(t, order, decoded)
+	require.NoError(t, codec.Decode(jsonCodec, data, strconv.Itoa(v2.ID), &decoded))
+	assert.Equal(t, order, decoded)
+
+	require.NoError(t, codec.Decode(jsonCodec, legacy, strconv.Itoa(v1.ID), &decoded), "field of the writer version")
+	assert.Equal(t, order, decoded)
+	assert.ErrorIs(t, codec.Decode(jsonCodec, legacy, strconv.Itoa(v2.ID), &decoded), schema.ErrIncompatible)
+	assert.ErrorContains(t, codec.Decode(jsonCodec, legacy, "", &decoded), `unknown field "legacy_note"`)
+
+	err = codec.Decode(jsonCodec, withField(`"colour":"red"`), strconv.Itoa(v2.ID), &decoded)
+	assert.ErrorIs(t, err, schema.ErrIncompatible)
+	assert.ErrorContains(t, err, "items[0].colour")
+
+	assert.ErrorIs(t, codec.Decode(jsonCodec, data, "9", &decoded), schema.ErrNotFound)
+}
@@ -0,0 +1,122 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hamba/avro/v2"
+
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/schema"
+)
+
+// JSON encodes orders as JSON objects whose field names are those of the
+// registered order schema. Fields unknown to the order model are rejected
+// rather than dropped, so that a producer that renamed or added a field is
+// caught instead of losing data. A payload sent with a schema id is checked
+// against that version instead: fields it declares but the current version
+// has removed are then accepted.
+type JSON struct {
+	registry schema.Registry
+	subject  string
+	latest   schema.Schema
+}
+
+func NewJSON(registry schema.Registry, subject string) (JSON, error) {
+	latest, err := registry.Latest(subject)
+	if err != nil {
+		return JSON{}, fmt.Errorf("failed to load json schema: %w", err)
+	}
+	return JSON{registry: registry, subject: subject, latest: latest}, nil
+}
+
+func (JSON) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (j JSON) SchemaID() int {
+	return j.latest.ID
+}
+
+func (JSON) Marshal(order *models.Order) ([]byte, error) {
+	return json.Marshal(order)
+}
+
+func (JSON) Unmarshal(data []byte, order *models.Order) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	*order = models.Order{}
+	return dec.Decode(order)
+}
+
+func (j JSON) UnmarshalSchema(data []byte, schemaID int, order *models.Order) error {
+	if j.registry == nil {
+		return fmt.Errorf("%w: id %d", schema.ErrNotFound, schemaID)
+	}
+	writer, err := j.registry.ByID(schemaID)
+	if err != nil {
+		return err
+	}
+	if writer.Subject != j.subject {
+		return fmt.Errorf("%w: id %d belongs to subject %q", schema.ErrNotFound, schemaID, writer.Subject)
+	}
+
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if err = checkFields(value, writer.Avro, ""); err != nil {
+		return fmt.Errorf("schema %d: %w", schemaID, err)
+	}
+
+	*order = models.Order{}
+	return json.Unmarshal(data, order)
+}
+
+// checkFields rejects the fields of a decoded JSON value that s does not
+// declare. Mismatched types are left to decoding into the model.
+func checkFields(value any, s avro.Schema, path string) error {
+	switch s := s.(type) {
+	case *avro.RecordSchema:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for name, field := range object {
+			i := indexOfField(s, name)
+			if i < 0 {
+				return fmt.Errorf("%w: field %s%s is not in the schema", schema.ErrIncompatible, path, name)
+			}
+			if err := checkFields(field, s.Fields()[i].Type(), path+name+"."); err != nil {
+				return err
+			}
+		}
+	case *avro.ArraySchema:
+		elements, _ := value.([]any)
+		for i, element := range elements {
+			if err := checkFields(element, s.Items(), fmt.Sprintf("%s[%d].", path[:max(len(path)-1, 0)], i)); err != nil {
+				return err
+			}
+		}
+	case *avro.UnionSchema:
+		if value == nil {
+			return nil
+		}
+		for _, t := range s.Types() {
+			if t.Type() != avro.Null {
+				return checkFields(value, t, path)
+			}
+		}
+	}
+	return nil
+}
+
+func indexOfField(s *avro.RecordSchema, name string) int {
+	for i, f := range s.Fields() {
+		if f.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,340 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"order-service-wb/internal/models"
+)
+
+// Protobuf encodes orders as the Order message of schemas/order.proto. The
+// message is small and stable, so it is written with protowire directly
+// rather than through generated code; the tests compile order.proto and round
+// trip every field of it through the codec, so the two cannot drift apart
+// unnoticed.
+type Protobuf struct{}
+
+func (Protobuf) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (Protobuf) Marshal(order *models.Order) ([]byte, error) {
+	var b []byte
+	b = appendString(b, 1, order.OrderUID)
+	b = appendString(b, 2, order.TrackNumber)
+	b = appendString(b, 3, order.Entry)
+	b = appendMessage(b, 4, marshalDelivery(&order.Delivery))
+	b = appendMessage(b, 5, marshalPayment(&order.Payment))
+	for i := range order.Items {
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, marshalItem(&order.Items[i]))
+	}
+	b = appendString(b, 7, order.Locale)
+	b = appendString(b, 8, order.InternalSig)
+	b = appendString(b, 9, order.CustomerID)
+	b = appendString(b, 10, order.DeliverySrv)
+	b = appendString(b, 11, order.ShardKey)
+	b = appendInt(b, 12, int64(order.SmID))
+	if !order.DateCreated.IsZero() {
+		b = appendMessage(b, 13, marshalTimestamp(order.DateCreated))
+	}
+	b = appendString(b, 14, order.OofShard)
+	b = appendInt(b, 15, order.Version)
+	b = appendString(b, 16, string(order.Status))
+	return b, nil
+}
+
+func (Protobuf) Unmarshal(data []byte, order *models.Order) error {
+	*order = models.Order{}
+	return consumeFields(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			order.OrderUID, err = f.string()
+		case 2:
+			order.TrackNumber, err = f.string()
+		case 3:
+			order.Entry, err = f.string()
+		case 4:
+			err = f.message(func(b []byte) error { return unmarshalDelivery(b, &order.Delivery) })
+		case 5:
+			err = f.message(func(b []byte) error { return unmarshalPayment(b, &order.Payment) })
+		case 6:
+			var item models.Item
+			if err = f.message(func(b []byte) error { return unmarshalItem(b, &item) }); err == nil {
+				order.Items = append(order.Items, item)
+			}
+		case 7:
+			order.Locale, err = f.string()
+		case 8:
+			order.InternalSig, err = f.string()
+		case 9:
+			order.CustomerID, err = f.string()
+		case 10:
+			order.DeliverySrv, err = f.string()
+		case 11:
+			order.ShardKey, err = f.string()
+		case 12:
+			order.SmID, err = f.int()
+		case 13:
+			err = f.message(func(b []byte) error { return unmarshalTimestamp(b, &order.DateCreated) })
+		case 14:
+			order.OofShard, err = f.string()
+		case 15:
+			order.Version, err = f.int64()
+		case 16:
+			var status string
+			status, err = f.string()
+			order.Status = models.OrderStatus(status)
+		}
+		return err
+	})
+}
+
+func marshalDelivery(d *models.Delivery) []byte {
+	var b []byte
+	b = appendString(b, 1, d.Name)
+	b = appendString(b, 2, d.Phone)
+	b = appendString(b, 3, d.Zip)
+	b = appendString(b, 4, d.City)
+	b = appendString(b, 5, d.Addr)
+	b = appendString(b, 6, d.Region)
+	b = appendString(b, 7, d.Email)
+	return b
+}
+
+func unmarshalDelivery(data []byte, d *models.Delivery) error {
+	return consumeFields(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			d.Name, err = f.string()
+		case 2:
+			d.Phone, err = f.string()
+		case 3:
+			d.Zip, err = f.string()
+		case 4:
+			d.City, err = f.string()
+		case 5:
+			d.Addr, err = f.string()
+		case 6:
+			d.Region, err = f.string()
+		case 7:
+			d.Email, err = f.string()
+		}
+		return err
+	})
+}
+
+func marshalPayment(p *models.Payment) []byte {
+	var b []byte
+	b = appendString(b, 1, p.Transaction)
+	b = appendString(b, 2, p.RequestID)
+	b = appendString(b, 3, p.Currency)
+	b = appendString(b, 4, p.Provider)
+	b = appendInt(b, 5, int64(p.Amount))
+	b = appendInt(b, 6, p.PaymentDT)
+	b = appendString(b, 7, p.Bank)
+	b = appendInt(b, 8, int64(p.DeliveryCost))
+	b = appendInt(b, 9, int64(p.GoodsTotal))
+	b = appendInt(b, 10, int64(p.CustomFee))
+	return b
+}
+
+func unmarshalPayment(data []byte, p *models.Payment) error {
+	return consumeFields(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			p.Transaction, err = f.string()
+		case 2:
+			p.RequestID, err = f.string()
+		case 3:
+			p.Currency, err = f.string()
+		case 4:
+			p.Provider, err = f.string()
+		case 5:
+			p.Amount, err = f.int()
+		case 6:
+			p.PaymentDT, err = f.int64()
+		case 7:
+			p.Bank, err = f.string()
+		case 8:
+			p.DeliveryCost, err = f.int()
+		case 9:
+			p.GoodsTotal, err = f.int()
+		case 10:
+			p.CustomFee, err = f.int()
+		}
+		return err
+	})
+}
+
+func marshalItem(item *models.Item) []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(item.ChrtID))
+	b = appendString(b, 2, item.TrackNumber)
+	b = appendInt(b, 3, int64(item.Price))
+	b = appendString(b, 4, item.Rid)
+	b = appendString(b, 5, item.Name)
+	b = appendInt(b, 6, int64(item.Sale))
+	b = appendString(b, 7, item.Size)
+	b = appendInt(b, 8, int64(item.TotalPrice))
+	b = appendInt(b, 9, int64(item.NmID))
+	b = appendString(b, 10, item.Brand)
+	b = appendInt(b, 11, int64(item.Status))
+	return b
+}
+
+func unmarshalItem(data []byte, item *models.Item) error {
+	return consumeFields(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			item.ChrtID, err = f.int()
+		case 2:
+			item.TrackNumber, err = f.string()
+		case 3:
+			item.Price, err = f.int()
+		case 4:
+			item.Rid, err = f.string()
+		case 5:
+			item.Name, err = f.string()
+		case 6:
+			item.Sale, err = f.int()
+		case 7:
+			item.Size, err = f.string()
+		case 8:
+			item.TotalPrice, err = f.int()
+		case 9:
+			item.NmID, err = f.int()
+		case 10:
+			item.Brand, err = f.string()
+		case 11:
+			item.Status, err = f.int()
+		}
+		return err
+	})
+}
+
+// marshalTimestamp encodes t as a google.protobuf.Timestamp.
+func marshalTimestamp(t time.Time) []byte {
+	var b []byte
+	b = appendInt(b, 1, t.Unix())
+	b = appendInt(b, 2, int64(t.Nanosecond()))
+	return b
+}
+
+func unmarshalTimestamp(data []byte, t *time.Time) error {
+	var seconds, nanos int64
+	err := consumeFields(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			seconds, err = f.int64()
+		case 2:
+			nanos, err = f.int64()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	*t = time.Unix(seconds, nanos).UTC()
+	return nil
+}
+
+// appendString and appendInt skip zero values, as proto3 does for scalar
+// fields without presence.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
+
+var errWireType = errors.New("unexpected wire type")
+
+// field is a decoded field value that has not been interpreted yet.
+type field struct {
+	typ   protowire.Type
+	value []byte
+}
+
+func (f field) string() (string, error) {
+	if f.typ != protowire.BytesType {
+		return "", errWireType
+	}
+	return string(f.value), nil
+}
+
+func (f field) message(unmarshal func([]byte) error) error {
+	if f.typ != protowire.BytesType {
+		return errWireType
+	}
+	return unmarshal(f.value)
+}
+
+func (f field) int64() (int64, error) {
+	if f.typ != protowire.VarintType {
+		return 0, errWireType
+	}
+	v, n := protowire.ConsumeVarint(f.value)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return int64(v), nil
+}
+
+func (f field) int() (int, error) {
+	v, err := f.int64()
+	return int(v), err
+}
+
+// consumeFields calls fn for every field of a message. Unknown fields are
+// passed to fn as well and ignored by it, which keeps old readers compatible
+// with newer writers.
+func consumeFields(data []byte, fn func(protowire.Number, field) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf tag: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			value = data[:max(n, 0)]
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf field %d: %w", num, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		if err := fn(num, field{typ: typ, value: value}); err != nil {
+			return fmt.Errorf("protobuf field %d: %w", num, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package codec_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"order-service-wb/internal/codec"
+	"order-service-wb/internal/models"
+)
+
+// fields are the values of a message by their names in order.proto.
+type fields map[string]any
+
+// orderDescriptor compiles schemas/order.proto, so that the hand-written
+// Protobuf codec is checked against the schema it implements.
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{schemasDir}}),
+	}
+	files, err := compiler.Compile(context.Background(), "order.proto")
+	require.NoError(t, err)
+
+	desc := files[0].Messages().ByName("Order")
+	require.NotNil(t, desc)
+	return desc
+}
+
+func orderFields(o *models.Order) fields {
+	items := make([]fields, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = fields{
+			"chrt_id":      int64(item.ChrtID),
+			"track_number": item.TrackNumber,
+			"price":        int64(item.Price),
+			"rid":          item.Rid,
+			"name":         item.Name,
+			"sale":         int64(item.Sale),
+			"size":         item.Size,
+			"total_price":  int64(item.TotalPrice),
+			"nm_id":        int64(item.NmID),
+			"brand":        item.Brand,
+			"status":       int64(item.Status),
+		}
+	}
+
+	return fields{
+		"order_uid":    o.OrderUID,
+		"track_number": o.TrackNumber,
+		"entry":        o.Entry,
+		"delivery": fields{
+			"name":    o.Delivery.Name,
+			"phone":   o.Delivery.Phone,
+			"zip":     o.Delivery.Zip,
+			"city":    o.Delivery.City,
+			"address": o.Delivery.Addr,
+			"region":  o.Delivery.Region,
+			"email":   o.Delivery.Email,
+		},
+		"payment": fields{
+			"transaction":   o.Payment.Transaction,
+			"request_id":    o.Payment.RequestID,
+			"currency":      o.Payment.Currency,
+			"provider":      o.Payment.Provider,
+			"amount":        int64(o.Payment.Amount),
+			"payment_dt":    o.Payment.PaymentDT,
+			"bank":          o.Payment.Bank,
+			"delivery_cost": int64(o.Payment.DeliveryCost),
+			"goods_total":   int64(o.Payment.GoodsTotal),
+			"custom_fee":    int64(o.Payment.CustomFee),
+		},
+		"items":              items,
+		"locale":             o.Locale,
+		"internal_signature": o.InternalSig,
+		"customer_id":        o.CustomerID,
+		"delivery_service":   o.DeliverySrv,
+		"shardkey":           o.ShardKey,
+		"sm_id":              int64(o.SmID),
+		"date_created": fields{
+			"seconds": o.DateCreated.Unix(),
+			"nanos":   int32(o.DateCreated.Nanosecond()),
+		},
+		"oof_shard": o.OofShard,
+		"version":   o.Version,
+		"status":    string(o.Status),
+	}
+}
+
+// fill sets the fields of msg by name. Every field of the message must be
+// given, so that a field added to order.proto but unknown to the codec fails
+// the test as well as a renamed or retyped one.
+func fill(t *testing.T, msg protoreflect.Message, values fields) {
+	desc := msg.Descriptor()
+	require.Equal(t, desc.Fields().Len(), len(values), "number of fields of %s", desc.FullName())
+
+	for name, value := range values {
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		require.NotNil(t, fd, "%s has no field %s", desc.FullName(), name)
+
+		switch v := value.(type) {
+		case string:
+			require.Equal(t, protoreflect.StringKind, fd.Kind(), fd.FullName())
+			msg.Set(fd, protoreflect.ValueOfString(v))
+		case int64:
+			require.Equal(t, protoreflect.Int64Kind, fd.Kind(), fd.FullName())
+			msg.Set(fd, protoreflect.ValueOfInt64(v))
+		case int32:
+			require.Equal(t, protoreflect.Int32Kind, fd.Kind(), fd.FullName())
+			msg.Set(fd, protoreflect.ValueOfInt32(v))
+		case fields:
+			require.Equal(t, protoreflect.MessageKind, fd.Kind(), fd.FullName())
+			fill(t, msg.Mutable(fd).Message(), v)
+		case []fields:
+			require.True(t, fd.IsList(), fd.FullName())
+			list := msg.Mutable(fd).List()
+			for _, element := range v {
+				value := list.NewElement()
+				fill(t, value.Message(), element)
+				list.Append(value)
+			}
+		default:
+			t.Fatalf("unsupported value %T of %s", value, fd.FullName())
+		}
+	}
+}
+
+func TestProtobuf_MatchesSchema(t *testing.T) {
+	desc := orderDescriptor(t)
+	order := testOrder()
+	expected := dynamicpb.NewMessage(desc)
+	fill(t, expected, orderFields(&order))
+
+	t.Run("marshal", func(t *testing.T) {
+		data, err := codec.Protobuf{}.Marshal(&order)
+		require.NoError(t, err)
+
+		decoded := dynamicpb.NewMessage(desc)
+		require.NoError(t, proto.Unmarshal(data, decoded))
+		assert.True(t, proto.Equal(expected, decoded), "expected:\n%s\ngot:\n%s",
+			prototext.Format(expected), prototext.Format(decoded))
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		data, err := proto.Marshal(expected)
+		require.NoError(t, err)
+
+		var decoded models.Order
+		require.NoError(t, codec.Protobuf{}.Unmarshal(data, &decoded))
+		assert.Equal(t, order, decoded)
+	})
+}
+
+// populate sets every field of msg, recursing into messages and lists, to a
+// value that no other field gets, so that a field the codec drops, swaps or
+// writes under a wrong number shows up in the round trip.
+func populate(t *testing.T, msg protoreflect.Message, next *int64) {
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		*next++
+
+		switch {
+		case fd.IsList():
+			require.Equal(t, protoreflect.MessageKind, fd.Kind(), fd.FullName())
+			list := msg.Mutable(fd).List()
+			for range 2 {
+				value := list.NewElement()
+				populate(t, value.Message(), next)
+				list.Append(value)
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			populate(t, msg.Mutable(fd).Message(), next)
+		case fd.Kind() == protoreflect.StringKind:
+			msg.Set(fd, protoreflect.ValueOfString(fmt.Sprintf("%s-%d", fd.Name(), *next)))
+		case fd.Kind() == protoreflect.Int64Kind:
+			msg.Set(fd, protoreflect.ValueOfInt64(*next))
+		case fd.Kind() == protoreflect.Int32Kind:
+			msg.Set(fd, protoreflect.ValueOfInt32(int32(*next)))
+		default:
+			t.Fatalf("unsupported kind %s of %s", fd.Kind(), fd.FullName())
+		}
+	}
+}
+
+func TestProtobuf_RoundTripsEveryField(t *testing.T) {
+	desc := orderDescriptor(t)
+	expected := dynamicpb.NewMessage(desc)
+	var next int64
+	populate(t, expected, &next)
+
+	data, err := proto.Marshal(expected)
+	require.NoError(t, err)
+
+	var order models.Order
+	require.NoError(t, codec.Protobuf{}.Unmarshal(data, &order))
+	data, err = codec.Protobuf{}.Marshal(&order)
+	require.NoError(t, err)
+
+	decoded := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(data, decoded))
+	assert.True(t, proto.Equal(expected, decoded), "expected:\n%s\ngot:\n%s",
+		prototext.Format(expected), prototext.Format(decoded))
+}
@@ -0,0 +1,45 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"order-service-wb/internal/health"
+)
+
+func TestChecker_Check(t *testing.T) {
+	var warm health.Flag
+
+	c := health.NewChecker(50 * time.Millisecond)
+	c.Register("database", func(context.Context) error { return nil })
+	c.Register("kafka", func(context.Context) error { return errors.New("no brokers") })
+	c.Register("cache", warm.Check)
+
+	report := c.Check(context.Background())
+	assert.False(t, report.Up())
+	assert.Equal(t, health.Result{Status: health.StatusUp}, report.Checks["database"])
+	assert.Equal(t, health.Result{Status: health.StatusDown, Error: "no brokers"}, report.Checks["kafka"])
+	assert.Equal(t, health.StatusDown, report.Checks["cache"].Status)
+
+	warm.Set()
+	assert.Equal(t, health.StatusUp, c.Check(context.Background()).Checks["cache"].Status)
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	c := health.NewChecker(20 * time.Millisecond)
+	c.Register("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := c.Check(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.False(t, report.Up())
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["stuck"].Error)
+}
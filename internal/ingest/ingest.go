@@ -0,0 +1,100 @@
+// Package ingest turns order records consumed from Kafka into service calls.
+// It is shared by the service and the replay tool so that replayed records
+// take exactly the path live ones do.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/codec"
+	"order-service-wb/internal/kafka"
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/schema"
+	"order-service-wb/internal/service"
+)
+
+type Ingester struct {
+	serv   service.OrderService
+	codecs *codec.Registry
+	logger *slog.Logger
+}
+
+func New(serv service.OrderService, codecs *codec.Registry, logger *slog.Logger) *Ingester {
+	return &Ingester{
+		serv:   serv,
+		codecs: codecs,
+		logger: logger,
+	}
+}
+
+// Handle decodes the order in record and stores it. Failures that will not
+// succeed on redelivery are returned as kafka.DeadLetterError.
+func (i *Ingester) Handle(ctx context.Context, record *kgo.Record) error {
+	order, err := i.decode(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	if err = i.serv.CreateOrder(ctx, order); err != nil {
+		i.logger.WarnContext(ctx, "failed to store order", logging.KeyOrderUID, order.OrderUID, logging.Err(err))
+		return classify(err)
+	}
+
+	i.logger.InfoContext(ctx, "order processed", logging.KeyOrderUID, order.OrderUID)
+	return nil
+}
+
+// Validate decodes and validates the order in record without storing it. It
+// fails the same way Handle would for records Handle rejects up front.
+func (i *Ingester) Validate(ctx context.Context, record *kgo.Record) error {
+	order, err := i.decode(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	if err = i.serv.ValidateOrder(ctx, order); err != nil {
+		i.logger.WarnContext(ctx, "invalid order", logging.KeyOrderUID, order.OrderUID, logging.Err(err))
+		return classify(err)
+	}
+	return nil
+}
+
+func (i *Ingester) decode(ctx context.Context, record *kgo.Record) (*models.Order, error) {
+	dec, err := i.codecs.Lookup(kafka.Header(record, kafka.HeaderContentType))
+	if err != nil {
+		i.logger.WarnContext(ctx, "unsupported Kafka message format", logging.Err(err))
+		return nil, kafka.NewDeadLetterError(kafka.ErrorClassContentType, err)
+	}
+
+	var order models.Order
+	if err = codec.Decode(dec, record.Value, kafka.Header(record, kafka.HeaderSchemaID), &order); err != nil {
+		i.logger.WarnContext(ctx, "invalid Kafka message", "content_type", dec.ContentType(), logging.Err(err))
+		if errors.Is(err, schema.ErrNotFound) || errors.Is(err, schema.ErrIncompatible) {
+			return nil, kafka.NewDeadLetterError(kafka.ErrorClassSchema, err)
+		}
+		return nil, kafka.NewDeadLetterError(kafka.ErrorClassDecode, err)
+	}
+	return &order, nil
+}
+
+// classify marks the service errors that are permanent for the record.
+func classify(err error) error {
+	if errors.Is(err, apperrors.ErrValidation) {
+		return kafka.NewDeadLetterError(kafka.ErrorClassValidation, err)
+	}
+	var conflictErr *service.OrderConflictError
+	if errors.As(err, &conflictErr) {
+		return kafka.NewDeadLetterError(kafka.ErrorClassConflict, err)
+	}
+	var staleErr *service.StaleVersionError
+	if errors.As(err, &staleErr) {
+		return kafka.NewDeadLetterError(kafka.ErrorClassStale, err)
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package ingest_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"order-service-wb/internal/codec"
+	"order-service-wb/internal/ingest"
+	"order-service-wb/internal/kafka"
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/service"
+	"order-service-wb/mocks"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func testOrder() models.Order {
+	return models.Order{
+		OrderUID:    "ingest-1",
+		TrackNumber: "WBTRACK",
+		Entry:       "WBIL",
+		Delivery: models.Delivery{
+			Name: "Test User", Phone: "+1234567890", Zip: "123456", City: "TestCity",
+			Addr: "123 Test St", Region: "TestRegion", Email: "test@example.com",
+		},
+		Payment: models.Payment{
+			Transaction: "ingest-1", RequestID: "1", Currency: "USD", Provider: "wbpay",
+			Amount: 1000, PaymentDT: 1637907727, Bank: "alpha", DeliveryCost: 500, GoodsTotal: 500,
+		},
+		Items: []models.Item{{
+			ChrtID: 1, TrackNumber: "WBTRACK", Price: 500, Rid: "r1", Name: "Product",
+			Size: "L", TotalPrice: 500, NmID: 1, Brand: "Brand", Status: 202,
+		}},
+		Locale:      "en",
+		CustomerID:  "testuser",
+		DateCreated: time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func newIngester(t *testing.T) (*ingest.Ingester, *mocks.OrderRepository) {
+	repo := mocks.NewOrderRepository(t)
+	c := new(mocks.Cache)
+	c.On("Set", mock.Anything, mock.Anything).Return().Maybe()
+
+	serv := service.NewOrderService(repo, c, nil, 0, discardLogger)
+	return ingest.New(serv, codec.NewRegistry(codec.JSON{}, codec.Protobuf{}), discardLogger), repo
+}
+
+func record(t *testing.T, order models.Order, c codec.Codec) *kgo.Record {
+	value, err := c.Marshal(&order)
+	require.NoError(t, err)
+	return &kgo.Record{
+		Topic:   "order",
+		Value:   value,
+		Headers: []kgo.RecordHeader{{Key: kafka.HeaderContentType, Value: []byte(c.ContentType())}},
+	}
+}
+
+func deadLetterClass(t *testing.T, err error) string {
+	dlErr, ok := kafka.AsDeadLetter(err)
+	require.True(t, ok, "expected a dead-letter error, got %v", err)
+	return dlErr.Class
+}
+
+func TestIngester_Handle(t *testing.T) {
+	ing, repo := newIngester(t)
+	repo.On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *models.Order) bool {
+		return o.OrderUID == "ingest-1"
+	})).Return(nil).Once()
+
+	require.NoError(t, ing.Handle(context.Background(), record(t, testOrder(), codec.Protobuf{})))
+}
+
+func TestIngester_RejectsUndecodableRecords(t *testing.T) {
+	ing, _ := newIngester(t)
+
+	unknown := record(t, testOrder(), codec.JSON{})
+	unknown.Headers[0].Value = []byte("text/csv")
+	assert.Equal(t, kafka.ErrorClassContentType, deadLetterClass(t, ing.Handle(context.Background(), unknown)))
+
+	malformed := &kgo.Record{Topic: "order", Value: []byte("{")}
+	assert.Equal(t, kafka.ErrorClassDecode, deadLetterClass(t, ing.Handle(context.Background(), malformed)))
+}
+
+func TestIngester_ValidateDoesNotStore(t *testing.T) {
+	ing, _ := newIngester(t)
+
+	require.NoError(t, ing.Validate(context.Background(), record(t, testOrder(), codec.JSON{})))
+
+	invalid := testOrder()
+	invalid.Items[0].Price = -1
+	data, err := json.Marshal(invalid)
+	require.NoError(t, err)
+	err = ing.Validate(context.Background(), &kgo.Record{Topic: "order", Value: data})
+	assert.Equal(t, kafka.ErrorClassValidation, deadLetterClass(t, err))
+}
@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/metrics"
+)
+
+const defaultCommitInterval = 5 * time.Second
+
+// CommitPolicy controls how often the offsets of handled records are
+// committed.
+type CommitPolicy struct {
+	// Interval between commits. Zero means 5s.
+	Interval time.Duration
+	// Batch triggers a commit as soon as this many records have been handled
+	// since the last one. Zero commits on the interval only.
+	Batch int
+}
+
+// committer commits the offsets of handled records in batches.
+type committer struct {
+	client *kgo.Client
+	policy CommitPolicy
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[topicPartition]pendingOffset
+	marked  int
+	full    chan struct{}
+}
+
+// pendingOffset is the next offset to commit for a partition and the number
+// of records it covers, for the committed-records metric.
+type pendingOffset struct {
+	offset  kgo.EpochOffset
+	records int
+}
+
+func newCommitter(client *kgo.Client, policy CommitPolicy, logger *slog.Logger) *committer {
+	if policy.Interval <= 0 {
+		policy.Interval = defaultCommitInterval
+	}
+	return &committer{
+		client:  client,
+		policy:  policy,
+		logger:  logger,
+		pending: make(map[topicPartition]pendingOffset),
+		full:    make(chan struct{}, 1),
+	}
+}
+
+// mark records that record has been handled. Records of a partition are
+// handled in order, so its offset is always the highest one pending.
+func (c *committer) mark(record *kgo.Record) {
+	c.mu.Lock()
+	tp := topicPartition{record.Topic, record.Partition}
+	p := c.pending[tp]
+	p.offset = kgo.EpochOffset{Epoch: record.LeaderEpoch, Offset: record.Offset + 1}
+	p.records++
+	c.pending[tp] = p
+	c.marked++
+	full := c.policy.Batch > 0 && c.marked >= c.policy.Batch
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run commits on every interval tick and whenever a batch fills up, until
+// ctx is cancelled.
+func (c *committer) run(ctx context.Context) {
+	ticker := time.NewTicker(c.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.full:
+		}
+		if err := c.flush(ctx); err != nil {
+			c.logger.ErrorContext(ctx, "failed to commit offsets", logging.Err(err))
+		}
+	}
+}
+
+// flush synchronously commits every pending offset. On failure the offsets
+// are kept for the next attempt unless newer ones were marked meanwhile.
+func (c *committer) flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[topicPartition]pendingOffset)
+	c.marked = 0
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for tp, p := range pending {
+		if offsets[tp.topic] == nil {
+			offsets[tp.topic] = make(map[int32]kgo.EpochOffset)
+		}
+		offsets[tp.topic][tp.partition] = p.offset
+	}
+
+	if err := c.commit(ctx, offsets); err != nil {
+		c.mu.Lock()
+		for tp, p := range pending {
+			c.marked += p.records
+			if newer, ok := c.pending[tp]; ok {
+				p.offset = newer.offset
+				p.records += newer.records
+			}
+			c.pending[tp] = p
+		}
+		c.mu.Unlock()
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
+
+	for tp, p := range pending {
+		metrics.KafkaRecordsCommitted.WithLabelValues(tp.topic, metrics.Partition(tp.partition)).Add(float64(p.records))
+	}
+	return nil
+}
+
+func (c *committer) commit(ctx context.Context, offsets map[string]map[int32]kgo.EpochOffset) error {
+	var err error
+	c.client.CommitOffsetsSync(ctx, offsets, func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, commitErr error) {
+		if commitErr != nil {
+			err = commitErr
+			return
+		}
+		for _, topic := range resp.Topics {
+			for _, partition := range topic.Partitions {
+				if partitionErr := kerr.ErrorForCode(partition.ErrorCode); partitionErr != nil {
+					err = partitionErr
+					return
+				}
+			}
+		}
+	})
+	return err
+}
+
+// forget drops the offsets of partitions that can no longer be committed.
+func (c *committer) forget(partitions map[string][]int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tp, p := range c.pending {
+		if slices.Contains(partitions[tp.topic], tp.partition) {
+			delete(c.pending, tp)
+			c.marked -= p.records
+		}
+	}
+}
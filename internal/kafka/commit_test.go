@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestCommitter_BatchThreshold(t *testing.T) {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers("127.0.0.1:1"),
+		kgo.ConsumerGroup("group"),
+		kgo.ConsumeTopics("order"),
+		kgo.DisableAutoCommit(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	c := newCommitter(client, CommitPolicy{Batch: 3}, slog.New(slog.DiscardHandler))
+
+	c.mark(&kgo.Record{Topic: "order", Partition: 0, Offset: 1})
+	c.mark(&kgo.Record{Topic: "order", Partition: 1, Offset: 7})
+	assert.Empty(t, c.full, "batch is not full yet")
+
+	c.mark(&kgo.Record{Topic: "order", Partition: 0, Offset: 2})
+	assert.Len(t, c.full, 1, "full batch must trigger a commit")
+	assert.Equal(t, pendingOffset{offset: kgo.EpochOffset{Offset: 3}, records: 2}, c.pending[topicPartition{"order", 0}])
+
+	c.forget(map[string][]int32{"order": {0}})
+	assert.Equal(t, map[topicPartition]pendingOffset{
+		{"order", 1}: {offset: kgo.EpochOffset{Offset: 8}, records: 1},
+	}, c.pending)
+	assert.Equal(t, 1, c.marked)
+}
@@ -2,49 +2,283 @@ package kafka
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/metrics"
 )
 
+// defaultDeadLetterDelay is the wait between dead-letter publish attempts
+// when the retry policy has no backoff.
+const defaultDeadLetterDelay = time.Second
+
 type Consumer struct {
-	client *kgo.Client
+	client     *kgo.Client
+	deadLetter recordSender
+	retry      RetryPolicy
+	commits    *committer
+	logger     *slog.Logger
+
+	// mu guards the per-partition workers, which are started and stopped by
+	// the group rebalance callbacks, and what they need to run. workCtx is
+	// passed to the handler and waitCtx bounds the backoff between retries.
+	mu      sync.Mutex
+	workers map[topicPartition]*partitionWorker
+	handler Handler
+	workCtx context.Context
+	waitCtx context.Context
+
+	// stopCtx is cancelled by Shutdown to stop fetching, abortCtx to cancel
+	// the handler of the record in flight. done is closed when Run returns.
+	stopCtx  context.Context
+	stop     context.CancelFunc
+	abortCtx context.Context
+	abort    context.CancelFunc
+	done     chan struct{}
 }
 
-func NewConsumer(brokers []string, group, topic string) (*Consumer, error) {
+// recordSender publishes records as they are; Producer implements it for the
+// dead-letter topic.
+type recordSender interface {
+	SendRecord(ctx context.Context, record *kgo.Record) error
+}
+
+// Handler processes a single record. ctx carries the record's correlation ID
+// for logging and the span continuing the producer's trace.
+type Handler func(ctx context.Context, record *kgo.Record) error
+
+// NewConsumer creates a group consumer that handles each assigned partition
+// in its own goroutine, so records are processed in order within a partition
+// and concurrently across partitions. Offsets of records that have been
+// handled or dead-lettered are committed in batches according to commit.
+// Records that fail are moved to deadLetter, which is required: a record is
+// never committed before it has been handled or dead-lettered.
+func NewConsumer(brokers []string, group, topic string, deadLetter *Producer, retry RetryPolicy, commit CommitPolicy, logger *slog.Logger) (*Consumer, error) {
+	if deadLetter == nil {
+		return nil, errors.New("dead-letter producer is required")
+	}
+	c := &Consumer{
+		deadLetter: deadLetter,
+		retry:      retry,
+		logger:     logger,
+		workers:    make(map[topicPartition]*partitionWorker),
+		done:       make(chan struct{}),
+	}
+
 	client, err := kgo.NewClient(
 		kgo.SeedBrokers(brokers...),
 		kgo.ConsumerGroup(group),
 		kgo.ConsumeTopics(topic),
+		kgo.DisableAutoCommit(),
+		kgo.OnPartitionsAssigned(c.assigned),
+		kgo.OnPartitionsRevoked(c.revoked),
+		kgo.OnPartitionsLost(c.lost),
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Consumer{client: client}, nil
+	c.client = client
+	c.commits = newCommitter(client, commit, logger)
+	c.stopCtx, c.stop = context.WithCancel(context.Background())
+	c.abortCtx, c.abort = context.WithCancel(context.Background())
+	return c, nil
 }
 
-func (c *Consumer) Run(ctx context.Context, handler func(msg *kgo.Record) error) {
+// Run fetches records and hands them to the partition workers until ctx is
+// cancelled or Shutdown is called. Fetching stops immediately, but the
+// records being handled are allowed to finish: handlers only see the
+// cancellation if Shutdown times out. A record waiting to be retried is not
+// retried again and stays uncommitted. Run returns once every worker is done.
+func (c *Consumer) Run(ctx context.Context, handler Handler) {
+	defer close(c.done)
+
+	pollCtx, cancelPoll := context.WithCancel(ctx)
+	defer cancelPoll()
+	defer context.AfterFunc(c.stopCtx, cancelPoll)()
+
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+	defer context.AfterFunc(c.abortCtx, cancelWork)()
+
+	c.mu.Lock()
+	c.handler, c.workCtx, c.waitCtx = handler, workCtx, pollCtx
+	c.mu.Unlock()
+
+	commitsDone := make(chan struct{})
+	go func() {
+		defer close(commitsDone)
+		c.commits.run(pollCtx)
+	}()
+	defer func() {
+		cancelPoll()
+		<-commitsDone
+	}()
+	defer c.stopWorkers(nil)
+
 	for {
-		fetches := c.client.PollFetches(ctx)
-		if errs := fetches.Errors(); len(errs) > 0 {
-			for _, err := range errs {
-				log.Printf("kafka fetch error: %v", err)
-			}
-			continue
+		fetches := c.client.PollFetches(pollCtx)
+		if pollCtx.Err() != nil || fetches.IsClientClosed() {
+			return
 		}
-		fetches.EachRecord(func(record *kgo.Record) {
-			if err := handler(record); err == nil {
-				if commitErr := c.client.CommitRecords(ctx, record); commitErr != nil {
-					log.Printf("commit error: %v", commitErr)
-				}
-			} else {
-				log.Printf("handler error: %v", err)
+		for _, err := range fetches.Errors() {
+			c.logger.ErrorContext(ctx, "kafka fetch error",
+				"topic", err.Topic, "partition", err.Partition, logging.Err(err.Err))
+		}
+		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+			if len(p.Records) == 0 {
+				return
+			}
+			c.mu.Lock()
+			w := c.workers[topicPartition{p.Topic, p.Partition}]
+			c.mu.Unlock()
+			if w == nil {
+				// Revoked while the fetch was in flight.
+				return
 			}
+			// Records fetched but not yet handled stay uncommitted and are
+			// redelivered to the next owner of the partition.
+			c.enqueue(w, p.Records)
 		})
 	}
 }
 
+// Shutdown stops fetching, waits for the records in flight to be handled,
+// commits their offsets, then leaves the consumer group and closes the
+// client. If ctx expires first, the handlers' context is cancelled and their
+// records are left uncommitted. Run must have been started.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.stop()
+
+	var err error
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.abort()
+		<-c.done
+		err = fmt.Errorf("in-flight records aborted: %w", ctx.Err())
+	}
+
+	if commitErr := c.commits.flush(ctx); commitErr != nil {
+		err = errors.Join(err, commitErr)
+	}
+	if leaveErr := c.client.LeaveGroupContext(ctx); leaveErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to leave consumer group: %w", leaveErr))
+	}
+	c.client.Close()
+	return err
+}
+
+// CorrelationID identifies a record by its position in the log.
+func CorrelationID(record *kgo.Record) string {
+	return fmt.Sprintf("%s/%d/%d", record.Topic, record.Partition, record.Offset)
+}
+
+// Header returns the value of the record header key, or "" if it is absent.
+func Header(record *kgo.Record, key string) string {
+	return recordCarrier{record}.Get(key)
+}
+
+// process handles record and marks it for commit once it has been handled or
+// dead-lettered. It reports false if the record was left unhandled, in which
+// case no later record of its partition may be marked either.
+func (c *Consumer) process(ctx, waitCtx context.Context, record *kgo.Record, handler Handler) bool {
+	ctx = logging.With(ctx, logging.KeyCorrelationID, CorrelationID(record))
+	ctx = otel.GetTextMapPropagator().Extract(ctx, recordCarrier{record})
+	ctx, span := tracer.Start(ctx, record.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(recordAttributes(record)...),
+		trace.WithAttributes(semconv.MessagingOperationTypeProcess),
+	)
+	defer span.End()
+
+	partition := metrics.Partition(record.Partition)
+	metrics.KafkaRecordsConsumed.WithLabelValues(record.Topic, partition).Inc()
+
+	start := time.Now()
+	err := c.retry.Do(waitCtx, func() error {
+		return handler(ctx, record)
+	})
+	metrics.KafkaHandlerDuration.WithLabelValues(record.Topic).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		c.commits.mark(record)
+		return true
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	if ctx.Err() != nil || (waitCtx.Err() != nil && errors.Is(err, waitCtx.Err())) {
+		c.logger.WarnContext(ctx, "handler interrupted by shutdown", logging.Err(err))
+		return false
+	}
+
+	metrics.KafkaRecordsFailed.WithLabelValues(record.Topic, partition).Inc()
+
+	dlErr, ok := AsDeadLetter(err)
+	switch {
+	case ok:
+	case c.retry.isRetryable(err):
+		dlErr = &DeadLetterError{Class: ErrorClassRetriesExhausted, Err: err}
+	default:
+		dlErr = &DeadLetterError{Class: ErrorClassUnexpected, Err: err}
+	}
+
+	if dlqErr := c.sendDeadLetter(ctx, waitCtx, deadLetterRecord(record, dlErr)); dlqErr != nil {
+		c.logger.WarnContext(ctx, "dead-letter publish interrupted by shutdown",
+			logging.Err(dlqErr), "cause", err)
+		return false
+	}
+
+	metrics.KafkaRecordsDeadLettered.WithLabelValues(record.Topic, dlErr.Class).Inc()
+	c.logger.WarnContext(ctx, "record moved to dead-letter topic",
+		"class", dlErr.Class, logging.Err(err))
+	c.commits.mark(record)
+	return true
+}
+
+// sendDeadLetter publishes record to the dead-letter topic, retrying with the
+// backoff of the retry policy until it succeeds or waitCtx is done. The delay
+// stops growing at the one before the last handler attempt.
+func (c *Consumer) sendDeadLetter(ctx, waitCtx context.Context, record *kgo.Record) error {
+	for attempt := 1; ; attempt++ {
+		err := c.deadLetter.SendRecord(ctx, record)
+		if err == nil {
+			return nil
+		}
+		c.logger.ErrorContext(ctx, "failed to publish record to dead-letter topic",
+			"attempt", attempt, logging.Err(err))
+
+		delay := c.retry.backoff(min(attempt, max(c.retry.MaxAttempts, 1)))
+		if delay <= 0 {
+			delay = defaultDeadLetterDelay
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-waitCtx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Ping checks that at least one broker of the cluster is reachable.
+func (c *Consumer) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx)
+}
+
 func (c *Consumer) Close() {
 	c.client.Close()
 }
@@ -0,0 +1,219 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var errTemporary = errors.New("temporary")
+
+var errBrokerUnavailable = errors.New("broker unavailable")
+
+// fakeSender records what is sent to the dead-letter topic. The first
+// failures sends fail; onSend, if set, is called on every send.
+type fakeSender struct {
+	failures int
+	onSend   func()
+	sent     []*kgo.Record
+}
+
+func (f *fakeSender) SendRecord(_ context.Context, record *kgo.Record) error {
+	f.sent = append(f.sent, record)
+	if f.onSend != nil {
+		f.onSend()
+	}
+	if len(f.sent) <= f.failures {
+		return errBrokerUnavailable
+	}
+	return nil
+}
+
+func newTestConsumer(t *testing.T, retry RetryPolicy) *Consumer {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers("127.0.0.1:1"),
+		kgo.ConsumerGroup("group"),
+		kgo.ConsumeTopics("order"),
+		kgo.DisableAutoCommit(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	logger := slog.New(slog.DiscardHandler)
+	return &Consumer{
+		client:  client,
+		retry:   retry,
+		commits: newCommitter(client, CommitPolicy{}, logger),
+		logger:  logger,
+	}
+}
+
+func TestConsumer_ProcessStopsBackoffOnShutdown(t *testing.T) {
+	c := newTestConsumer(t, RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Hour,
+		Retryable:   func(err error) bool { return errors.Is(err, errTemporary) },
+	})
+
+	waitCtx, stop := context.WithCancel(context.Background())
+	attempts := 0
+	handler := func(ctx context.Context, _ *kgo.Record) error {
+		attempts++
+		stop()
+		return errTemporary
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.process(context.Background(), waitCtx, &kgo.Record{Topic: "order", Offset: 4}, handler)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("process kept waiting to retry after shutdown")
+	}
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, c.commits.pending, "interrupted record must stay uncommitted")
+}
+
+func TestDeadLetterRecord_Headers(t *testing.T) {
+	record := &kgo.Record{
+		Topic:     "order",
+		Partition: 2,
+		Offset:    17,
+		Key:       []byte("uid"),
+		Value:     []byte("{"),
+		Headers:   []kgo.RecordHeader{{Key: HeaderContentType, Value: []byte("application/json")}},
+	}
+
+	dl := deadLetterRecord(record, &DeadLetterError{Class: ErrorClassDecode, Err: errors.New("unexpected EOF")})
+
+	assert.Equal(t, record.Key, dl.Key)
+	assert.Equal(t, record.Value, dl.Value)
+	assert.Empty(t, dl.Topic, "the producer sets the dead-letter topic")
+	assert.Equal(t, []kgo.RecordHeader{
+		{Key: HeaderContentType, Value: []byte("application/json")},
+		{Key: HeaderOriginalTopic, Value: []byte("order")},
+		{Key: HeaderOriginalPartition, Value: []byte("2")},
+		{Key: HeaderOriginalOffset, Value: []byte("17")},
+		{Key: HeaderErrorClass, Value: []byte(ErrorClassDecode)},
+		{Key: HeaderErrorMessage, Value: []byte("unexpected EOF")},
+	}, dl.Headers)
+}
+
+func TestConsumer_ProcessDeadLetters(t *testing.T) {
+	record := &kgo.Record{Topic: "order", Partition: 0, Offset: 9}
+	tp := topicPartition{"order", 0}
+
+	tests := []struct {
+		name         string
+		handlerErr   error
+		sendFailures int
+		class        string
+	}{
+		{
+			name:       "permanent failure",
+			handlerErr: NewDeadLetterError(ErrorClassValidation, errors.New("invalid")),
+			class:      ErrorClassValidation,
+		},
+		{
+			name:       "retries exhausted",
+			handlerErr: errTemporary,
+			class:      ErrorClassRetriesExhausted,
+		},
+		{
+			name:       "unexpected failure",
+			handlerErr: errors.New("unexpected"),
+			class:      ErrorClassUnexpected,
+		},
+		{
+			name:         "dead-letter publish is retried",
+			handlerErr:   NewDeadLetterError(ErrorClassDecode, errors.New("bad payload")),
+			sendFailures: 2,
+			class:        ErrorClassDecode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConsumer(t, RetryPolicy{
+				MaxAttempts: 2,
+				BaseDelay:   time.Millisecond,
+				Retryable:   func(err error) bool { return errors.Is(err, errTemporary) },
+			})
+			sender := &fakeSender{failures: tt.sendFailures}
+			c.deadLetter = sender
+
+			ok := c.process(context.Background(), context.Background(), record, func(context.Context, *kgo.Record) error {
+				return tt.handlerErr
+			})
+
+			assert.True(t, ok)
+			require.Len(t, sender.sent, tt.sendFailures+1)
+			assert.Equal(t, tt.class, Header(sender.sent[0], HeaderErrorClass))
+			assert.Equal(t, "9", Header(sender.sent[0], HeaderOriginalOffset))
+			assert.Equal(t, int64(10), c.commits.pending[tp].offset.Offset)
+		})
+	}
+}
+
+func TestNewConsumer_RequiresDeadLetter(t *testing.T) {
+	_, err := NewConsumer([]string{"127.0.0.1:1"}, "group", "order", nil, RetryPolicy{}, CommitPolicy{}, slog.New(slog.DiscardHandler))
+
+	assert.Error(t, err)
+}
+
+func TestConsumer_FailedRecordHaltsPartition(t *testing.T) {
+	c := newTestConsumer(t, RetryPolicy{BaseDelay: time.Hour})
+	waitCtx, stop := context.WithCancel(context.Background())
+	// The dead-letter topic stays unavailable until shutdown starts.
+	c.deadLetter = &fakeSender{failures: math.MaxInt, onSend: stop}
+
+	var handled []int64
+	c.handler = func(_ context.Context, record *kgo.Record) error {
+		handled = append(handled, record.Offset)
+		if record.Offset == 5 {
+			return NewDeadLetterError(ErrorClassDecode, errors.New("bad payload"))
+		}
+		return nil
+	}
+	c.workCtx, c.waitCtx = context.Background(), waitCtx
+
+	w := &partitionWorker{
+		tp:    topicPartition{"order", 0},
+		ready: make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	c.workers = map[topicPartition]*partitionWorker{w.tp: w}
+	c.enqueue(w, []*kgo.Record{
+		{Topic: "order", Offset: 4},
+		{Topic: "order", Offset: 5},
+		{Topic: "order", Offset: 6},
+	})
+	go c.work(w)
+
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("worker kept waiting to publish after shutdown")
+	}
+	assert.Equal(t, []int64{4, 5}, handled)
+	assert.Equal(t, int64(5), c.commits.pending[w.tp].offset.Offset, "commit must stop at the failed record")
+	assert.Equal(t, w.tp.partitions(), c.client.PauseFetchPartitions(nil))
+
+	c.enqueue(w, []*kgo.Record{{Topic: "order", Offset: 7}})
+	assert.Empty(t, w.queue)
+
+	c.stopWorkers(nil)
+	assert.Empty(t, c.client.PauseFetchPartitions(nil))
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderErrorClass        = "x-error-class"
+	HeaderErrorMessage      = "x-error-message"
+
+	// HeaderContentType names the wire format of the record value.
+	HeaderContentType = "content-type"
+	// HeaderSchemaID is the registry id of the schema the value was written
+	// with, for formats that have one.
+	HeaderSchemaID = "schema-id"
+)
+
+const (
+	ErrorClassDecode      = "decode"
+	ErrorClassContentType = "content_type"
+	ErrorClassSchema      = "schema"
+	ErrorClassValidation  = "validation"
+	ErrorClassConflict    = "conflict"
+	ErrorClassStale       = "stale_version"
+	// ErrorClassRetriesExhausted is used for transient failures that did not
+	// recover within the configured retry policy.
+	ErrorClassRetriesExhausted = "retries_exhausted"
+	// ErrorClassUnexpected is used for handler errors that are neither
+	// permanent nor transient.
+	ErrorClassUnexpected = "unexpected"
+)
+
+// DeadLetterError marks a handler error as permanent: the record will never
+// succeed on redelivery and should be moved to the dead-letter topic.
+type DeadLetterError struct {
+	Class string
+	Err   error
+}
+
+func NewDeadLetterError(class string, err error) error {
+	return &DeadLetterError{Class: class, Err: err}
+}
+
+func (e *DeadLetterError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Class, e.Err)
+}
+
+func (e *DeadLetterError) Unwrap() error {
+	return e.Err
+}
+
+func AsDeadLetter(err error) (*DeadLetterError, bool) {
+	var dlErr *DeadLetterError
+	if errors.As(err, &dlErr) {
+		return dlErr, true
+	}
+	return nil, false
+}
+
+func deadLetterRecord(record *kgo.Record, dlErr *DeadLetterError) *kgo.Record {
+	headers := make([]kgo.RecordHeader, 0, len(record.Headers)+5)
+	headers = append(headers, record.Headers...)
+	headers = append(headers,
+		kgo.RecordHeader{Key: HeaderOriginalTopic, Value: []byte(record.Topic)},
+		kgo.RecordHeader{Key: HeaderOriginalPartition, Value: []byte(strconv.FormatInt(int64(record.Partition), 10))},
+		kgo.RecordHeader{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(record.Offset, 10))},
+		kgo.RecordHeader{Key: HeaderErrorClass, Value: []byte(dlErr.Class)},
+		kgo.RecordHeader{Key: HeaderErrorMessage, Value: []byte(dlErr.Err.Error())},
+	)
+
+	return &kgo.Record{
+		Key:     record.Key,
+		Value:   record.Value,
+		Headers: headers,
+	}
+}
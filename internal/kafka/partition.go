@@ -0,0 +1,213 @@
+package kafka
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"order-service-wb/internal/logging"
+)
+
+// workerQueue is the number of fetched batches a partition worker buffers
+// before fetching of its partition is paused. Run never blocks on a slow
+// worker, so the other partitions keep being fetched.
+const workerQueue = 4
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type partitionWorker struct {
+	tp topicPartition
+
+	// mu guards the queued batches and the pause state of the partition,
+	// which is only changed while holding it so that a pause and a resume
+	// never cross.
+	mu      sync.Mutex
+	queue   [][]*kgo.Record
+	paused  bool
+	stopped bool
+
+	ready chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+}
+
+// assigned starts a worker for every newly assigned partition. A partition
+// that already has one keeps it.
+func (c *Consumer) assigned(_ context.Context, _ *kgo.Client, assigned map[string][]int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for topic, partitions := range assigned {
+		for _, partition := range partitions {
+			tp := topicPartition{topic, partition}
+			if _, ok := c.workers[tp]; ok {
+				continue
+			}
+			w := &partitionWorker{
+				tp:    tp,
+				ready: make(chan struct{}, 1),
+				quit:  make(chan struct{}),
+				done:  make(chan struct{}),
+			}
+			c.workers[tp] = w
+			go c.work(w)
+		}
+	}
+}
+
+// revoked stops the workers of the revoked partitions and commits what they
+// have handled before the partitions move to another member. Offsets that
+// fail to commit are dropped rather than retried for partitions no longer
+// owned; their records are redelivered to the new owner.
+func (c *Consumer) revoked(ctx context.Context, _ *kgo.Client, revoked map[string][]int32) {
+	c.stopWorkers(revoked)
+	if err := c.commits.flush(ctx); err != nil {
+		c.logger.ErrorContext(ctx, "failed to commit offsets of revoked partitions", logging.Err(err))
+		c.commits.forget(revoked)
+	}
+}
+
+// lost stops the workers of partitions that already belong to another member;
+// their offsets can no longer be committed.
+func (c *Consumer) lost(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+	c.stopWorkers(lost)
+	c.commits.forget(lost)
+}
+
+// stopWorkers stops the workers of the given partitions, or of all partitions
+// if nil, and waits for the records they are handling to finish. Partitions
+// paused for a stopped worker are resumed, so that they are fetched again if
+// they are assigned back.
+func (c *Consumer) stopWorkers(partitions map[string][]int32) {
+	c.mu.Lock()
+	var stopped []*partitionWorker
+	for tp, w := range c.workers {
+		if partitions != nil && !slices.Contains(partitions[tp.topic], tp.partition) {
+			continue
+		}
+		close(w.quit)
+		delete(c.workers, tp)
+		stopped = append(stopped, w)
+	}
+	c.mu.Unlock()
+
+	for _, w := range stopped {
+		w.mu.Lock()
+		w.stopped = true
+		w.queue = nil
+		if w.paused {
+			w.paused = false
+			c.client.ResumeFetchPartitions(w.tp.partitions())
+		}
+		w.mu.Unlock()
+	}
+	for _, w := range stopped {
+		<-w.done
+	}
+}
+
+// enqueue hands a fetched batch to w without blocking. Once w has
+// workerQueue batches waiting, its partition is paused until it catches up;
+// the batch is still queued, as records fetched after it are not returned
+// while the partition is paused.
+func (c *Consumer) enqueue(w *partitionWorker, records []*kgo.Record) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.queue = append(w.queue, records)
+	if len(w.queue) >= workerQueue && !w.paused {
+		w.paused = true
+		c.client.PauseFetchPartitions(w.tp.partitions())
+	}
+
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+// dequeue takes the oldest batch queued for w, resuming its partition once
+// there is room for more.
+func (c *Consumer) dequeue(w *partitionWorker) ([]*kgo.Record, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.queue) == 0 {
+		return nil, false
+	}
+	records := w.queue[0]
+	w.queue[0] = nil
+	w.queue = w.queue[1:]
+
+	if w.paused && len(w.queue) < workerQueue {
+		w.paused = false
+		c.client.ResumeFetchPartitions(w.tp.partitions())
+	}
+	return records, true
+}
+
+func (c *Consumer) work(w *partitionWorker) {
+	defer close(w.done)
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-w.ready:
+		}
+
+		for {
+			records, ok := c.dequeue(w)
+			if !ok {
+				break
+			}
+
+			c.mu.Lock()
+			ctx, waitCtx, handler := c.workCtx, c.waitCtx, c.handler
+			c.mu.Unlock()
+
+			for _, record := range records {
+				select {
+				case <-w.quit:
+					return
+				default:
+				}
+				if !c.process(ctx, waitCtx, record, handler) {
+					c.halt(w)
+					return
+				}
+			}
+		}
+	}
+}
+
+// halt stops w at a record it could not handle: its partition is paused and
+// the batches queued after the record are dropped, so that no later offset
+// is committed past it. The record is redelivered once the partition is
+// assigned again.
+func (c *Consumer) halt(w *partitionWorker) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	w.queue = nil
+	if !w.paused {
+		w.paused = true
+		c.client.PauseFetchPartitions(w.tp.partitions())
+	}
+}
+
+func (tp topicPartition) partitions() map[string][]int32 {
+	return map[string][]int32{tp.topic: {tp.partition}}
+}
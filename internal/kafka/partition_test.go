@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestConsumer_SlowWorkerPausesItsPartition(t *testing.T) {
+	c := newTestConsumer(t, RetryPolicy{})
+	w := &partitionWorker{tp: topicPartition{"order", 1}, ready: make(chan struct{}, 1)}
+	batch := []*kgo.Record{{Topic: "order", Partition: 1}}
+
+	for range workerQueue - 1 {
+		c.enqueue(w, batch)
+	}
+	assert.Empty(t, c.client.PauseFetchPartitions(nil), "queue is not full yet")
+
+	c.enqueue(w, batch)
+	c.enqueue(w, batch)
+	assert.Equal(t, map[string][]int32{"order": {1}}, c.client.PauseFetchPartitions(nil))
+	assert.Len(t, w.queue, workerQueue+1, "batches fetched before the pause are kept")
+
+	_, ok := c.dequeue(w)
+	assert.True(t, ok)
+	assert.Equal(t, map[string][]int32{"order": {1}}, c.client.PauseFetchPartitions(nil))
+
+	_, ok = c.dequeue(w)
+	assert.True(t, ok)
+	assert.Empty(t, c.client.PauseFetchPartitions(nil), "partition resumes once the worker catches up")
+}
+
+func TestConsumer_StopResumesPausedPartition(t *testing.T) {
+	c := newTestConsumer(t, RetryPolicy{})
+	// The worker is not running, so nothing drains its queue.
+	w := &partitionWorker{
+		tp:    topicPartition{"order", 0},
+		ready: make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	close(w.done)
+	c.workers = map[topicPartition]*partitionWorker{w.tp: w}
+
+	for range workerQueue - 1 {
+		c.enqueue(w, nil)
+	}
+	c.enqueue(w, nil)
+	assert.NotEmpty(t, c.client.PauseFetchPartitions(nil))
+
+	c.stopWorkers(nil)
+	assert.Empty(t, c.client.PauseFetchPartitions(nil))
+
+	c.enqueue(w, nil)
+	assert.Empty(t, c.client.PauseFetchPartitions(nil), "stopped worker must not pause its partition")
+}
+
+func TestConsumer_AssignedKeepsExistingWorker(t *testing.T) {
+	c := newTestConsumer(t, RetryPolicy{})
+	c.workers = make(map[topicPartition]*partitionWorker)
+
+	c.assigned(context.Background(), c.client, map[string][]int32{"order": {0}})
+	first := c.workers[topicPartition{"order", 0}]
+	c.assigned(context.Background(), c.client, map[string][]int32{"order": {0, 1}})
+
+	assert.Same(t, first, c.workers[topicPartition{"order", 0}])
+	assert.Len(t, c.workers, 2)
+
+	c.stopWorkers(nil)
+	assert.Empty(t, c.workers)
+}
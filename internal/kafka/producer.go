@@ -2,10 +2,16 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"order-service-wb/internal/tracing"
 )
 
 type Producer struct {
@@ -14,6 +20,9 @@ type Producer struct {
 }
 
 func NewProducer(brokers []string, topic string) (*Producer, error) {
+	if topic == "" {
+		return nil, errors.New("producer topic is not set")
+	}
 	client, err := kgo.NewClient(
 		kgo.SeedBrokers(brokers...),
 		kgo.RecordDeliveryTimeout(5*time.Second),
@@ -29,12 +38,40 @@ func NewProducer(brokers []string, topic string) (*Producer, error) {
 
 }
 
+// Send publishes a record and injects the trace context of ctx into its
+// headers so that the consumer continues the same trace.
 func (p *Producer) Send(ctx context.Context, key string, value []byte) error {
-	return p.client.ProduceSync(ctx, &kgo.Record{
-		Topic: p.topic,
-		Key:   []byte(key),
-		Value: value,
-	}).FirstErr()
+	return p.SendWithHeaders(ctx, key, value)
+}
+
+// SendWithHeaders is Send with additional record headers, such as
+// HeaderContentType.
+func (p *Producer) SendWithHeaders(ctx context.Context, key string, value []byte, headers ...kgo.RecordHeader) (err error) {
+	ctx, span := tracer.Start(ctx, p.topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(p.topic),
+			semconv.MessagingKafkaMessageKey(key),
+		),
+	)
+	defer tracing.End(span, &err)
+
+	record := &kgo.Record{
+		Topic:   p.topic,
+		Key:     []byte(key),
+		Value:   value,
+		Headers: headers,
+	}
+	otel.GetTextMapPropagator().Inject(ctx, recordCarrier{record})
+
+	return p.client.ProduceSync(ctx, record).FirstErr()
+}
+
+func (p *Producer) SendRecord(ctx context.Context, record *kgo.Record) error {
+	record.Topic = p.topic
+	return p.client.ProduceSync(ctx, record).FirstErr()
 }
 
 func (p *Producer) Close() {
@@ -0,0 +1,301 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"order-service-wb/internal/logging"
+)
+
+// ReplayOptions selects the records of a topic to replay.
+type ReplayOptions struct {
+	Topic string
+	// Partitions limits the replay to the given partitions; empty means all.
+	Partitions []int32
+	// Offset is where every partition starts when Since is zero. A negative
+	// Offset starts at the earliest retained record.
+	Offset int64
+	// Since starts at the first record produced at or after it.
+	Since time.Time
+	// Until stops before the first record produced at or after it. Zero
+	// replays up to the end of the partitions at planning time.
+	Until time.Time
+}
+
+// ReplayRange is the span of offsets [Start, End) of a partition to replay.
+type ReplayRange struct {
+	Topic     string
+	Partition int32
+	Start     int64
+	End       int64
+}
+
+type ReplayStats struct {
+	Processed int
+	Failed    int
+}
+
+// PlanReplay resolves opts into the offset ranges to replay. The end of each
+// range is fixed when planning, so records produced during the replay are
+// left to the regular consumer.
+func PlanReplay(ctx context.Context, client *kgo.Client, opts ReplayOptions) ([]ReplayRange, error) {
+	adm := kadm.NewClient(client)
+
+	list := func(what string, fn func() (kadm.ListedOffsets, error)) (kadm.ListedOffsets, error) {
+		offsets, err := fn()
+		if err == nil {
+			err = offsets.Error()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s offsets of %s: %w", what, opts.Topic, err)
+		}
+		return offsets, nil
+	}
+
+	starts, err := list("start", func() (kadm.ListedOffsets, error) { return adm.ListStartOffsets(ctx, opts.Topic) })
+	if err != nil {
+		return nil, err
+	}
+	ends, err := list("end", func() (kadm.ListedOffsets, error) { return adm.ListEndOffsets(ctx, opts.Topic) })
+	if err != nil {
+		return nil, err
+	}
+
+	var since, until kadm.ListedOffsets
+	if !opts.Since.IsZero() {
+		since, err = list("since", func() (kadm.ListedOffsets, error) {
+			return adm.ListOffsetsAfterMilli(ctx, opts.Since.UnixMilli(), opts.Topic)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !opts.Until.IsZero() {
+		until, err = list("until", func() (kadm.ListedOffsets, error) {
+			return adm.ListOffsetsAfterMilli(ctx, opts.Until.UnixMilli(), opts.Topic)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return planRanges(opts, starts, ends, since, until)
+}
+
+// planRanges computes the replay ranges from listed offsets; since and until
+// are nil when the corresponding option is not set.
+func planRanges(opts ReplayOptions, starts, ends, since, until kadm.ListedOffsets) ([]ReplayRange, error) {
+	partitions := opts.Partitions
+	if len(partitions) == 0 {
+		partitions = slices.Sorted(maps.Keys(ends[opts.Topic]))
+	}
+
+	ranges := make([]ReplayRange, 0, len(partitions))
+	for _, p := range partitions {
+		end, ok := ends.Lookup(opts.Topic, p)
+		if !ok {
+			return nil, fmt.Errorf("partition %d of %s does not exist", p, opts.Topic)
+		}
+		first, _ := starts.Lookup(opts.Topic, p)
+
+		r := ReplayRange{Topic: opts.Topic, Partition: p, Start: first.Offset, End: end.Offset}
+		switch {
+		case since != nil:
+			if o, ok := since.Lookup(opts.Topic, p); ok && o.Offset >= 0 {
+				r.Start = o.Offset
+			}
+		case opts.Offset >= 0:
+			r.Start = max(opts.Offset, first.Offset)
+		}
+		if until != nil {
+			if o, ok := until.Lookup(opts.Topic, p); ok && o.Offset >= 0 {
+				r.End = min(r.End, o.Offset)
+			}
+		}
+		r.Start = min(r.Start, r.End)
+
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// RewindGroup commits the start of every range as the offset of group, so
+// that the group consumes the ranges again once it is restarted. Kafka only
+// accepts this while the group has no active members.
+func RewindGroup(ctx context.Context, client *kgo.Client, group string, ranges []ReplayRange) error {
+	offsets := make(kadm.Offsets)
+	for _, r := range ranges {
+		offsets.Add(kadm.Offset{Topic: r.Topic, Partition: r.Partition, At: r.Start, LeaderEpoch: -1})
+	}
+
+	resp, err := kadm.NewClient(client).CommitOffsets(ctx, group, offsets)
+	if err == nil {
+		err = resp.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewind group %s, make sure none of its consumers are running: %w", group, err)
+	}
+	return nil
+}
+
+// replayIdle is how long Replay waits for records of the partitions it has
+// not finished. A fetch returns the retained records below the high watermark
+// without waiting, so a partition that stays silent this long has none left
+// before the end of its range, e.g. because they were compacted away.
+var replayIdle = 10 * time.Second
+
+// Replay feeds the records of ranges to handler, retrying as retry allows.
+// It reads the partitions directly, outside of any consumer group, so it
+// neither joins nor commits for the group of the service. Records that fail
+// are logged and counted; they are not dead-lettered again. A fetch error
+// stops the replay.
+func Replay(ctx context.Context, brokers []string, ranges []ReplayRange, handler Handler, retry RetryPolicy, logger *slog.Logger) (ReplayStats, error) {
+	var stats ReplayStats
+
+	// pending holds the unfinished ranges; their start moves past every
+	// record seen.
+	pending := make(map[topicPartition]ReplayRange)
+	consume := make(map[string]map[int32]kgo.Offset)
+	for _, r := range ranges {
+		if r.Start >= r.End {
+			continue
+		}
+		pending[topicPartition{r.Topic, r.Partition}] = r
+		if consume[r.Topic] == nil {
+			consume[r.Topic] = make(map[int32]kgo.Offset)
+		}
+		consume[r.Topic][r.Partition] = kgo.NewOffset().At(r.Start)
+	}
+	if len(pending) == 0 {
+		return stats, nil
+	}
+
+	// Control records are kept so that a range ending in a transaction
+	// marker is seen to be complete without waiting.
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumePartitions(consume),
+		kgo.KeepControlRecords(),
+	)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer client.Close()
+
+	// A finished partition stops being fetched, so that records produced to
+	// it meanwhile do not keep the others from going idle.
+	finish := func(tp topicPartition) {
+		delete(pending, tp)
+		client.PauseFetchPartitions(tp.partitions())
+	}
+
+	for len(pending) > 0 {
+		pollCtx, cancel := context.WithTimeout(ctx, replayIdle)
+		fetches := client.PollFetches(pollCtx)
+		cancel()
+		if err = ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		var fetchErr error
+		fetches.EachError(func(topic string, partition int32, err error) {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				fetchErr = errors.Join(fetchErr, fmt.Errorf("failed to fetch partition %d of %s: %w", partition, topic, err))
+			}
+		})
+		if fetchErr != nil {
+			return stats, fetchErr
+		}
+
+		if fetches.NumRecords() == 0 {
+			if err = finishIdle(ctx, client, pending, logger); err != nil {
+				return stats, err
+			}
+			continue
+		}
+
+		fetches.EachRecord(func(record *kgo.Record) {
+			tp := topicPartition{record.Topic, record.Partition}
+			r, ok := pending[tp]
+			if !ok {
+				return
+			}
+			if record.Offset >= r.End {
+				finish(tp)
+				return
+			}
+			r.Start = record.Offset + 1
+			if r.Start >= r.End {
+				finish(tp)
+			} else {
+				pending[tp] = r
+			}
+			if record.Attrs.IsControl() {
+				return
+			}
+
+			if replayRecord(ctx, record, handler, retry, logger) {
+				stats.Processed++
+			} else {
+				stats.Failed++
+			}
+		})
+	}
+
+	return stats, nil
+}
+
+// finishIdle ends the pending ranges after no records arrived for replayIdle.
+// The cluster is checked to be reachable first, so that an outage is reported
+// rather than taken for the end of the ranges.
+func finishIdle(ctx context.Context, client *kgo.Client, pending map[topicPartition]ReplayRange, logger *slog.Logger) error {
+	topics := make([]string, 0, len(pending))
+	for tp := range pending {
+		if !slices.Contains(topics, tp.topic) {
+			topics = append(topics, tp.topic)
+		}
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, replayIdle)
+	defer cancel()
+	ends, err := kadm.NewClient(client).ListEndOffsets(checkCtx, topics...)
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("no records received for %s and failed to reach the cluster: %w", replayIdle, err)
+	}
+
+	for tp, r := range pending {
+		logger.WarnContext(ctx, "no records left before the end of the replay range",
+			"topic", tp.topic, "partition", tp.partition, "next", r.Start, "end", r.End)
+		delete(pending, tp)
+	}
+	return nil
+}
+
+func replayRecord(ctx context.Context, record *kgo.Record, handler Handler, retry RetryPolicy, logger *slog.Logger) bool {
+	ctx = logging.With(ctx, logging.KeyCorrelationID, CorrelationID(record))
+
+	err := retry.Do(ctx, func() error {
+		return handler(ctx, record)
+	})
+	if err == nil {
+		return true
+	}
+
+	if dlErr, ok := AsDeadLetter(err); ok {
+		logger.ErrorContext(ctx, "failed to replay record", "class", dlErr.Class, logging.Err(err))
+	} else {
+		logger.ErrorContext(ctx, "failed to replay record", logging.Err(err))
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func listed(topic string, offsets ...int64) kadm.ListedOffsets {
+	l := kadm.ListedOffsets{topic: {}}
+	for p, o := range offsets {
+		l[topic][int32(p)] = kadm.ListedOffset{Topic: topic, Partition: int32(p), Offset: o}
+	}
+	return l
+}
+
+func TestPlanRanges(t *testing.T) {
+	starts := listed("order", 0, 10, 0)
+	ends := listed("order", 100, 50, 0)
+
+	tests := []struct {
+		name         string
+		opts         ReplayOptions
+		since, until kadm.ListedOffsets
+		want         []ReplayRange
+	}{
+		{
+			name: "from the earliest record",
+			opts: ReplayOptions{Topic: "order", Offset: -1},
+			want: []ReplayRange{{"order", 0, 0, 100}, {"order", 1, 10, 50}, {"order", 2, 0, 0}},
+		},
+		{
+			name: "from an offset in selected partitions",
+			opts: ReplayOptions{Topic: "order", Partitions: []int32{1, 0}, Offset: 5},
+			want: []ReplayRange{{"order", 1, 10, 50}, {"order", 0, 5, 100}},
+		},
+		{
+			name:  "between timestamps",
+			opts:  ReplayOptions{Topic: "order", Partitions: []int32{0, 1}, Since: time.Unix(1, 0), Until: time.Unix(2, 0)},
+			since: listed("order", 40, 45),
+			until: listed("order", 60, 30),
+			want:  []ReplayRange{{"order", 0, 40, 60}, {"order", 1, 30, 30}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planRanges(tt.opts, starts, ends, tt.since, tt.until)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := planRanges(ReplayOptions{Topic: "order", Partitions: []int32{3}}, starts, ends, nil, nil)
+	assert.Error(t, err, "unknown partition")
+}
+
+func TestReplay_EndOffsetWithoutRecord(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "order"))
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	brokers := cluster.ListenAddrs()
+
+	producer, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.DefaultProduceTopic("order"))
+	require.NoError(t, err)
+	t.Cleanup(producer.Close)
+	for _, value := range []string{"a", "b", "c"} {
+		require.NoError(t, producer.ProduceSync(context.Background(), &kgo.Record{Value: []byte(value)}).FirstErr())
+	}
+
+	idle := replayIdle
+	replayIdle = 200 * time.Millisecond
+	t.Cleanup(func() { replayIdle = idle })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The range ends past the last record, as when the records at its tail
+	// have been compacted away.
+	var replayed []string
+	stats, err := Replay(ctx, brokers, []ReplayRange{{"order", 0, 0, 5}}, func(_ context.Context, record *kgo.Record) error {
+		replayed = append(replayed, string(record.Value))
+		return nil
+	}, RetryPolicy{}, slog.New(slog.DiscardHandler))
+
+	require.NoError(t, err)
+	assert.Equal(t, ReplayStats{Processed: 3}, stats)
+	assert.Equal(t, []string{"a", "b", "c"}, replayed)
+}
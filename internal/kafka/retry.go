@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how many times a record handler is re-invoked on
+// transient failures and how long the consumer waits between attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is the fraction (0..1) of each delay that is randomised.
+	Jitter float64
+	// Retryable reports whether err is transient. A nil func disables retries.
+	Retryable func(err error) bool
+}
+
+func (p RetryPolicy) isRetryable(err error) bool {
+	if _, ok := AsDeadLetter(err); ok {
+		return false
+	}
+	return p.Retryable != nil && p.Retryable(err)
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+
+	return delay
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error or the attempts
+// are exhausted. If ctx is done while waiting for the next attempt, the
+// context error is returned.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	attempts := max(p.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !p.isRetryable(err) || attempt >= attempts {
+			return err
+		}
+
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"order-service-wb/internal/kafka"
+)
+
+var errTransient = errors.New("transient")
+
+func newTestPolicy(maxAttempts int) kafka.RetryPolicy {
+	return kafka.RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+		Jitter:      0.5,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errTransient)
+		},
+	}
+}
+
+func TestRetryPolicy_RecoversFromTransientError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := newTestPolicy(3).Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryPolicy_StopsAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := newTestPolicy(4).Do(context.Background(), func() error {
+		calls++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 4, calls)
+}
+
+func TestRetryPolicy_DoesNotRetryPermanentErrors(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := newTestPolicy(5).Do(context.Background(), func() error {
+		calls++
+		return kafka.NewDeadLetterError(kafka.ErrorClassValidation, errTransient)
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_RespectsContextCancellation(t *testing.T) {
+	t.Parallel()
+
+	policy := newTestPolicy(10)
+	policy.BaseDelay = time.Hour
+	policy.MaxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := policy.Do(ctx, func() error {
+		calls++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"order-service-wb/internal/tracing"
+)
+
+var tracer = tracing.Tracer("order-service-wb/internal/kafka")
+
+// recordCarrier exposes the headers of a record to OpenTelemetry propagators
+// so that trace context travels with the message.
+type recordCarrier struct {
+	record *kgo.Record
+}
+
+func (c recordCarrier) Get(key string) string {
+	for _, h := range c.record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c recordCarrier) Set(key, value string) {
+	for i, h := range c.record.Headers {
+		if h.Key == key {
+			c.record.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.record.Headers = append(c.record.Headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
+
+func (c recordCarrier) Keys() []string {
+	keys := make([]string, len(c.record.Headers))
+	for i, h := range c.record.Headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+func recordAttributes(record *kgo.Record) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKafka,
+		semconv.MessagingDestinationName(record.Topic),
+		semconv.MessagingDestinationPartitionID(strconv.Itoa(int(record.Partition))),
+		semconv.MessagingKafkaOffset(int(record.Offset)),
+		semconv.MessagingKafkaMessageKey(string(record.Key)),
+	}
+}
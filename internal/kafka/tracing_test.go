@@ -0,0 +1,35 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRecordCarrier_RoundTrip(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	record := &kgo.Record{Headers: []kgo.RecordHeader{
+		{Key: "traceparent", Value: []byte("stale")},
+		{Key: HeaderOriginalTopic, Value: []byte("order")},
+	}}
+
+	var prop propagation.TraceContext
+	prop.Inject(ctx, recordCarrier{record})
+
+	assert.Len(t, record.Headers, 2, "existing header must be replaced, not duplicated")
+
+	got := trace.SpanContextFromContext(prop.Extract(context.Background(), recordCarrier{record}))
+	assert.Equal(t, sc.TraceID(), got.TraceID())
+	assert.Equal(t, sc.SpanID(), got.SpanID())
+	assert.True(t, got.IsRemote())
+}
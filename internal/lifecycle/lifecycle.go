@@ -0,0 +1,75 @@
+// Package lifecycle coordinates the shutdown of the service as an ordered
+// list of phases, each bounded by its own timeout.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"order-service-wb/internal/logging"
+)
+
+type phase struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+type Manager struct {
+	phases []phase
+	logger *slog.Logger
+}
+
+func NewManager(logger *slog.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Add appends a phase. stop receives a context that expires after timeout;
+// zero means the phase is only bounded by the context passed to Shutdown.
+// Once its context expires, stop is expected to abort its work and return
+// promptly.
+func (m *Manager) Add(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	m.phases = append(m.phases, phase{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs the phases in the order they were added. A phase that fails
+// or overruns its timeout is logged and the remaining phases still run, but
+// only once it has returned, so that no phase releases resources the one
+// before it is still using. The returned error joins the errors of all
+// phases.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, p := range m.phases {
+		start := time.Now()
+		err := p.run(ctx)
+		if err != nil {
+			m.logger.ErrorContext(ctx, "shutdown phase failed",
+				"phase", p.name, "duration", time.Since(start), logging.Err(err))
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+			continue
+		}
+		m.logger.InfoContext(ctx, "shutdown phase completed",
+			"phase", p.name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// run waits for stop to return. A stop function that overruns its timeout
+// has seen its context expire and is waiting for its work to abort; the phase
+// still fails with the deadline even if it then returns no error.
+func (p phase) run(ctx context.Context) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	err := p.stop(ctx)
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
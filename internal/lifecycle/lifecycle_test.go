@@ -0,0 +1,69 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"order-service-wb/internal/lifecycle"
+)
+
+func TestManager_Shutdown(t *testing.T) {
+	started := make(chan string, 4)
+	errDB := errors.New("close failed")
+
+	m := lifecycle.NewManager(slog.New(slog.DiscardHandler))
+	m.Add("consumer", time.Second, func(context.Context) error {
+		started <- "consumer"
+		return nil
+	})
+	m.Add("http", 20*time.Millisecond, func(ctx context.Context) error {
+		started <- "http"
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	m.Add("database", 0, func(context.Context) error {
+		started <- "database"
+		return errDB
+	})
+
+	err := m.Shutdown(context.Background())
+
+	var order []string
+	for range cap(started) - 1 {
+		order = append(order, <-started)
+	}
+	assert.Equal(t, []string{"consumer", "http", "database"}, order)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, errDB)
+	assert.ErrorContains(t, err, "http: ")
+}
+
+func TestManager_ShutdownWaitsForOverrunningPhase(t *testing.T) {
+	var aborted atomic.Bool
+
+	m := lifecycle.NewManager(slog.New(slog.DiscardHandler))
+	m.Add("consumer", 20*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		// Aborting the work in flight takes longer than the timeout.
+		time.Sleep(50 * time.Millisecond)
+		aborted.Store(true)
+		return nil
+	})
+	var abortedBeforeDatabase bool
+	m.Add("database", 0, func(context.Context) error {
+		abortedBeforeDatabase = aborted.Load()
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+
+	assert.True(t, abortedBeforeDatabase)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "consumer: ")
+}
@@ -0,0 +1,46 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/logging"
+)
+
+func TestNew_ContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "debug")
+	require.NoError(t, err)
+
+	ctx := logging.With(context.Background(), logging.KeyRequestID, "req-1")
+	ctx = logging.With(ctx, logging.KeyOrderUID, "order-1")
+	logger.WarnContext(ctx, "failed to store order", logging.Err(errors.New("boom")))
+
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, "WARN", rec["level"])
+	assert.Equal(t, "failed to store order", rec["msg"])
+	assert.Equal(t, "req-1", rec[logging.KeyRequestID])
+	assert.Equal(t, "order-1", rec[logging.KeyOrderUID])
+	assert.Equal(t, "boom", rec[logging.KeyError])
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, "warn")
+	require.NoError(t, err)
+
+	logger.Info("dropped")
+	assert.Zero(t, buf.Len())
+
+	_, err = logging.New(&buf, "xml", "")
+	assert.Error(t, err)
+	_, err = logging.New(&buf, "", "verbose")
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+// Package metrics holds the Prometheus collectors of the service and the
+// HTTP handler exposing them.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"order-service-wb/internal/cache"
+)
+
+const namespace = "order_service"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	KafkaRecordsConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "records_consumed_total",
+		Help:      "Kafka records received by the consumer.",
+	}, []string{"topic", "partition"})
+
+	KafkaRecordsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "records_failed_total",
+		Help:      "Kafka records whose handler failed after all retries.",
+	}, []string{"topic", "partition"})
+
+	KafkaRecordsCommitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "records_committed_total",
+		Help:      "Kafka records whose offsets were committed.",
+	}, []string{"topic", "partition"})
+
+	KafkaRecordsDeadLettered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "records_dead_lettered_total",
+		Help:      "Kafka records moved to the dead-letter topic.",
+	}, []string{"topic", "class"})
+
+	KafkaHandlerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "handler_duration_seconds",
+		Help:      "Time spent handling a Kafka record, including retries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	OutboxEventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_published_total",
+		Help:      "Outbox events published to Kafka.",
+	}, []string{"type"})
+
+	RepositoryQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of repository operations.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests served.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		KafkaRecordsConsumed,
+		KafkaRecordsFailed,
+		KafkaRecordsCommitted,
+		KafkaRecordsDeadLettered,
+		KafkaHandlerDuration,
+		OutboxEventsPublished,
+		RepositoryQueryDuration,
+		HTTPRequests,
+		HTTPRequestDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCache exports the statistics of c. They are read on every scrape.
+func RegisterCache(c cache.Cache) {
+	registry.MustRegister(&cacheCollector{cache: c})
+}
+
+// ObserveQuery records the latency of a repository operation started at
+// start. It is meant to be deferred.
+func ObserveQuery(operation string, start time.Time) {
+	RepositoryQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// GinMiddleware records request counts and latencies labelled by the route
+// template rather than the raw path to keep cardinality bounded.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Partition formats a Kafka partition number as a label value.
+func Partition(partition int32) string {
+	return strconv.FormatInt(int64(partition), 10)
+}
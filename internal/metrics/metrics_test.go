@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/cache"
+	"order-service-wb/internal/models"
+)
+
+func TestGinMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The vectors are shared with the other tests of the package, so only
+	// the series this test creates are checked.
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(HTTPRequests, HTTPRequestDuration)
+
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.GET("/order/:order_uid", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+
+	for _, path := range []string{"/order/first-uid", "/order/second-uid", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "/order/:order_uid", "418")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var histogramSamples uint64
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				assert.NotContains(t, label.GetValue(), "-uid", "raw path used as %s label", label.GetName())
+			}
+			if family.GetName() == "order_service_http_request_duration_seconds" && hasLabel(metric.GetLabel(), "route", "/order/:order_uid") {
+				histogramSamples += metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.Equal(t, uint64(2), histogramSamples)
+}
+
+func TestCacheCollector(t *testing.T) {
+	c, err := cache.New(cache.Options{Policy: cache.PolicyLRU, Size: 1})
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(&cacheCollector{cache: c})
+
+	c.Set("a", models.Order{OrderUID: "a"})
+	c.Get("a")
+	c.Get("missing")
+	c.Set("b", models.Order{OrderUID: "b"})
+
+	expected := `
+# HELP order_service_cache_evictions_total Orders evicted from the cache by its size or TTL limits.
+# TYPE order_service_cache_evictions_total counter
+order_service_cache_evictions_total 1
+# HELP order_service_cache_hits_total Order cache lookups that found the order.
+# TYPE order_service_cache_hits_total counter
+order_service_cache_hits_total 1
+# HELP order_service_cache_misses_total Order cache lookups that did not find the order.
+# TYPE order_service_cache_misses_total counter
+order_service_cache_misses_total 1
+# HELP order_service_cache_size Orders currently held in the cache.
+# TYPE order_service_cache_size gauge
+order_service_cache_size 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected)))
+}
+
+func hasLabel(labels []*dto.LabelPair, name, value string) bool {
+	for _, label := range labels {
+		if label.GetName() == name && label.GetValue() == value {
+			return true
+		}
+	}
+	return false
+}
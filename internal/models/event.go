@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventOrderCreated       EventType = "OrderCreated"
+	EventOrderUpdated       EventType = "OrderUpdated"
+	EventOrderStatusChanged EventType = "OrderStatusChanged"
+)
+
+// OrderEvent is a domain event written to the outbox together with the change
+// it describes and later published to Kafka keyed by OrderUID. Payload holds
+// the order for OrderCreated and OrderUpdated and a StatusChange for
+// OrderStatusChanged.
+type OrderEvent struct {
+	ID         int64           `json:"id"`
+	Type       EventType       `json:"type"`
+	OrderUID   string          `json:"order_uid"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type StatusChange struct {
+	From OrderStatus `json:"from"`
+	To   OrderStatus `json:"to"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"order-service-wb/internal/apperrors"
+)
+
+var ErrInvalidCursor = fmt.Errorf("invalid cursor: %w", apperrors.ErrValidation)
+
+// OrderFilter describes an order search. Zero values mean "no restriction".
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	CreatedFrom     time.Time
+	CreatedTo       time.Time
+	PaymentProvider string
+	Currency        string
+	ItemBrand       string
+	After           *OrderCursor
+	Limit           int
+}
+
+// OrderCursor points at the last order of a page. Orders are listed newest
+// first, ordered by (date_created, order_uid).
+type OrderCursor struct {
+	DateCreated time.Time
+	OrderUID    string
+}
+
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func (c OrderCursor) Encode() string {
+	raw := c.DateCreated.UTC().Format(time.RFC3339Nano) + "|" + c.OrderUID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeOrderCursor(s string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	ts, uid, ok := strings.Cut(string(raw), "|")
+	if !ok || uid == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	created, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &OrderCursor{DateCreated: created, OrderUID: uid}, nil
+}
@@ -0,0 +1,107 @@
+// Package outbox relays the domain events written to the outbox table to
+// Kafka.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/metrics"
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/repository"
+)
+
+// Publisher sends a message keyed by order_uid, so that the events of an
+// order land on the same partition in the order they were sent.
+type Publisher interface {
+	Send(ctx context.Context, key string, value []byte) error
+}
+
+// Relay periodically publishes outbox events and deletes them once the
+// publisher has acknowledged them. An event is deleted only after it was
+// sent, so delivery is at-least-once: a crash between the two repeats it.
+type Relay struct {
+	repo      repository.OutboxRepository
+	publisher Publisher
+	interval  time.Duration
+	batch     int
+	logger    *slog.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewRelay(repo repository.OutboxRepository, publisher Publisher, interval time.Duration, batch int, logger *slog.Logger) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		interval:  interval,
+		batch:     batch,
+		logger:    logger,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run relays events every interval until ctx is cancelled or Shutdown is
+// called. A full batch is followed by the next one without waiting.
+func (r *Relay) Run(ctx context.Context) {
+	defer close(r.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stop:
+			return
+		case <-timer.C:
+		}
+
+		sent, err := r.repo.RelayOutbox(ctx, r.batch, r.publish)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "failed to relay outbox events", "sent", sent, logging.Err(err))
+		}
+
+		if err == nil && sent == r.batch {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.interval)
+		}
+	}
+}
+
+// Shutdown stops Run after the batch in progress and waits for it to return.
+// Run must have been started.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	close(r.stop)
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// publish sends events one by one and stops at the first failure, so a later
+// event of an order is never published before an earlier one.
+func (r *Relay) publish(ctx context.Context, events []models.OrderEvent) (int, error) {
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return i, fmt.Errorf("failed to encode event %d: %w", event.ID, err)
+		}
+		if err = r.publisher.Send(ctx, event.OrderUID, value); err != nil {
+			return i, fmt.Errorf("failed to publish event %d: %w", event.ID, err)
+		}
+		metrics.OutboxEventsPublished.WithLabelValues(string(event.Type)).Inc()
+	}
+	return len(events), nil
+}
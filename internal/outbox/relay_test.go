@@ -0,0 +1,90 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/outbox"
+	"order-service-wb/internal/repository"
+	"order-service-wb/mocks"
+)
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	keys   []string
+	values [][]byte
+	failAt int
+}
+
+func (p *fakePublisher) Send(_ context.Context, key string, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys)+1 == p.failAt {
+		return errors.New("broker unavailable")
+	}
+	p.keys = append(p.keys, key)
+	p.values = append(p.values, value)
+	return nil
+}
+
+func TestRelay_StopsAtFirstFailedEvent(t *testing.T) {
+	events := []models.OrderEvent{
+		{ID: 1, Type: models.EventOrderCreated, OrderUID: "a", Payload: json.RawMessage(`{}`)},
+		{ID: 2, Type: models.EventOrderStatusChanged, OrderUID: "a", Payload: json.RawMessage(`{"from":"created","to":"paid"}`)},
+		{ID: 3, Type: models.EventOrderCreated, OrderUID: "b", Payload: json.RawMessage(`{}`)},
+	}
+
+	relayed := make(chan struct{})
+	repo := mocks.NewOutboxRepository(t)
+	repo.On("RelayOutbox", mock.Anything, 10, mock.Anything).
+		Return(func(ctx context.Context, _ int, publish repository.PublishFunc) (int, error) {
+			defer close(relayed)
+			sent, err := publish(ctx, events)
+			assert.Equal(t, 1, sent)
+			assert.Error(t, err)
+			return sent, err
+		}).Once()
+
+	publisher := &fakePublisher{failAt: 2}
+	relay := outbox.NewRelay(repo, publisher, time.Hour, 10, slog.New(slog.DiscardHandler))
+	go relay.Run(context.Background())
+
+	<-relayed
+	require.NoError(t, relay.Shutdown(context.Background()))
+
+	require.Equal(t, []string{"a"}, publisher.keys)
+	var got models.OrderEvent
+	require.NoError(t, json.Unmarshal(publisher.values[0], &got))
+	assert.Equal(t, events[0].ID, got.ID)
+	assert.Equal(t, models.EventOrderCreated, got.Type)
+}
+
+func TestRelay_FullBatchIsFollowedImmediately(t *testing.T) {
+	relayed := make(chan struct{})
+	repo := mocks.NewOutboxRepository(t)
+	repo.On("RelayOutbox", mock.Anything, 2, mock.Anything).Return(2, nil).Once()
+	repo.On("RelayOutbox", mock.Anything, 2, mock.Anything).
+		Run(func(mock.Arguments) { close(relayed) }).
+		Return(0, nil).Once()
+
+	relay := outbox.NewRelay(repo, &fakePublisher{}, time.Hour, 2, slog.New(slog.DiscardHandler))
+	go relay.Run(context.Background())
+
+	select {
+	case <-relayed:
+	case <-time.After(time.Second):
+		t.Fatal("next batch was not relayed without waiting for the interval")
+	}
+	require.NoError(t, relay.Shutdown(context.Background()))
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/lib/pq"
+
+	"order-service-wb/internal/apperrors"
+)
+
+// ErrOrderExists is returned by CreateOrder when an order with the same
+// order_uid is already stored.
+var ErrOrderExists = fmt.Errorf("order already exists: %w", apperrors.ErrConflict)
+
+// ErrStaleVersion is returned by UpdateOrder when the stored order already
+// has the same or a newer version.
+var ErrStaleVersion = fmt.Errorf("stale order version: %w", apperrors.ErrConflict)
+
+// ErrStatusChanged is returned by UpdateOrderStatus when the order is no
+// longer in the expected status because of a concurrent transition.
+var ErrStatusChanged = fmt.Errorf("order status changed concurrently: %w", apperrors.ErrConflict)
+
+// ErrIncompleteOrder is returned when a stored order lacks its payment or
+// delivery row.
+var ErrIncompleteOrder = errors.New("stored order is incomplete")
+
+// dbError tags database errors with the matching domain error kind: missing
+// rows become ErrNotFound, deadlines ErrTimeout and failures that may succeed
+// on retry ErrUnavailable. Other errors are returned unchanged.
+func dbError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", apperrors.ErrNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", apperrors.ErrTimeout, err)
+	case isTransient(err):
+		return fmt.Errorf("%w: %w", apperrors.ErrUnavailable, err)
+	default:
+		return err
+	}
+}
+
+// isTransient reports whether err is a database failure that may succeed if
+// the operation is retried: lost connections, serialization failures,
+// deadlocks and server-side resource exhaustion.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", // connection exception
+			"53", // insufficient resources
+			"57": // operator intervention (admin shutdown, crash shutdown)
+			return true
+		}
+		switch pqErr.Code.Name() {
+		case "serialization_failure", "deadlock_detected":
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
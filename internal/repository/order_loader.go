@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/models"
+)
+
+type itemRow struct {
+	OrderUID string `db:"order_uid"`
+	models.Item
+}
+
+type paymentRow struct {
+	OrderUID string `db:"order_uid"`
+	models.Payment
+}
+
+type deliveryRow struct {
+	OrderUID string `db:"order_uid"`
+	models.Delivery
+}
+
+// selectOrders runs a query returning orders rows and loads their items,
+// payments and deliveries with one query per table, all inside a single
+// read-only snapshot. The order of the rows returned by q is preserved.
+func (r *orderRepo) selectOrders(ctx context.Context, q string, args ...any) ([]*models.Order, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", dbError(err))
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	var orders []*models.Order
+	if err = tx.SelectContext(ctx, &orders, q, args...); err != nil {
+		r.logger.ErrorContext(ctx, "failed to select orders", logging.Err(err))
+		return nil, fmt.Errorf("failed to select orders: %w", dbError(err))
+	}
+
+	if err = r.loadOrderDetails(ctx, tx, orders); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", dbError(err))
+	}
+
+	return orders, nil
+}
+
+func (r *orderRepo) loadOrderDetails(ctx context.Context, tx *sqlx.Tx, orders []*models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.OrderUID)
+	}
+
+	var items []itemRow
+	q := `SELECT
+			order_uid, chrt_id, track_number, price, rid, name, sale,
+			size, total_price, nm_id, brand, status
+		FROM items WHERE order_uid = ANY($1)
+		ORDER BY order_uid, id
+		`
+	if err := tx.SelectContext(ctx, &items, q, pq.Array(ids)); err != nil {
+		r.logger.ErrorContext(ctx, "failed to get items for orders", logging.Err(err))
+		return fmt.Errorf("failed to get items for orders: %w", dbError(err))
+	}
+
+	var payments []paymentRow
+	q = `SELECT
+			order_uid, transaction, request_id, currency, provider,
+			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
+		FROM payment WHERE order_uid = ANY($1)
+		`
+	if err := tx.SelectContext(ctx, &payments, q, pq.Array(ids)); err != nil {
+		r.logger.ErrorContext(ctx, "failed to get payments for orders", logging.Err(err))
+		return fmt.Errorf("failed to get payments for orders: %w", dbError(err))
+	}
+
+	var deliveries []deliveryRow
+	q = `SELECT
+			order_uid, name, phone, zip, city, address, region, email
+		FROM delivery WHERE order_uid = ANY($1)
+		`
+	if err := tx.SelectContext(ctx, &deliveries, q, pq.Array(ids)); err != nil {
+		r.logger.ErrorContext(ctx, "failed to get deliveries for orders", logging.Err(err))
+		return fmt.Errorf("failed to get deliveries for orders: %w", dbError(err))
+	}
+
+	if err := assembleOrders(orders, items, payments, deliveries); err != nil {
+		r.logger.ErrorContext(ctx, "failed to load orders", logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+// assembleOrders attaches the child rows to their orders. Items keep the
+// order of their rows. Every order must have exactly one payment and one
+// delivery row; a missing one fails with ErrIncompleteOrder rather than
+// leaving zero values in the order.
+func assembleOrders(orders []*models.Order, items []itemRow, payments []paymentRow, deliveries []deliveryRow) error {
+	byID := make(map[string]*models.Order, len(orders))
+	for _, order := range orders {
+		byID[order.OrderUID] = order
+	}
+	lookup := func(table, orderUID string) (*models.Order, error) {
+		order, ok := byID[orderUID]
+		if !ok {
+			return nil, fmt.Errorf("unexpected %s row of order %s", table, orderUID)
+		}
+		return order, nil
+	}
+
+	for _, item := range items {
+		order, err := lookup("items", item.OrderUID)
+		if err != nil {
+			return err
+		}
+		order.Items = append(order.Items, item.Item)
+	}
+
+	hasPayment := make(map[string]bool, len(payments))
+	for _, payment := range payments {
+		order, err := lookup("payment", payment.OrderUID)
+		if err != nil {
+			return err
+		}
+		order.Payment = payment.Payment
+		hasPayment[payment.OrderUID] = true
+	}
+
+	hasDelivery := make(map[string]bool, len(deliveries))
+	for _, delivery := range deliveries {
+		order, err := lookup("delivery", delivery.OrderUID)
+		if err != nil {
+			return err
+		}
+		order.Delivery = delivery.Delivery
+		hasDelivery[delivery.OrderUID] = true
+	}
+
+	for _, order := range orders {
+		if !hasPayment[order.OrderUID] {
+			return fmt.Errorf("%w: order %s has no payment", ErrIncompleteOrder, order.OrderUID)
+		}
+		if !hasDelivery[order.OrderUID] {
+			return fmt.Errorf("%w: order %s has no delivery", ErrIncompleteOrder, order.OrderUID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/models"
+)
+
+func TestAssembleOrders(t *testing.T) {
+	first, second := &models.Order{OrderUID: "b"}, &models.Order{OrderUID: "a"}
+
+	err := assembleOrders(
+		[]*models.Order{first, second},
+		[]itemRow{
+			{OrderUID: "a", Item: models.Item{Rid: "a1"}},
+			{OrderUID: "a", Item: models.Item{Rid: "a2"}},
+			{OrderUID: "b", Item: models.Item{Rid: "b1"}},
+			{OrderUID: "a", Item: models.Item{Rid: "a3"}},
+		},
+		[]paymentRow{
+			{OrderUID: "a", Payment: models.Payment{Transaction: "a"}},
+			{OrderUID: "b", Payment: models.Payment{Transaction: "b"}},
+		},
+		[]deliveryRow{
+			{OrderUID: "b", Delivery: models.Delivery{Name: "b"}},
+			{OrderUID: "a", Delivery: models.Delivery{Name: "a"}},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.Item{{Rid: "b1"}}, first.Items)
+	assert.Equal(t, []models.Item{{Rid: "a1"}, {Rid: "a2"}, {Rid: "a3"}}, second.Items)
+	assert.Equal(t, "b", first.Payment.Transaction)
+	assert.Equal(t, "a", second.Payment.Transaction)
+	assert.Equal(t, "b", first.Delivery.Name)
+	assert.Equal(t, "a", second.Delivery.Name)
+}
+
+func TestAssembleOrders_OrderWithoutItems(t *testing.T) {
+	order := &models.Order{OrderUID: "a"}
+
+	err := assembleOrders([]*models.Order{order}, nil,
+		[]paymentRow{{OrderUID: "a"}}, []deliveryRow{{OrderUID: "a"}})
+
+	require.NoError(t, err)
+	assert.Empty(t, order.Items)
+}
+
+func TestAssembleOrders_MissingRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		payments   []paymentRow
+		deliveries []deliveryRow
+		message    string
+	}{
+		{
+			name:       "payment",
+			deliveries: []deliveryRow{{OrderUID: "a"}, {OrderUID: "b"}},
+			payments:   []paymentRow{{OrderUID: "a"}},
+			message:    "order b has no payment",
+		},
+		{
+			name:       "delivery",
+			payments:   []paymentRow{{OrderUID: "a"}, {OrderUID: "b"}},
+			deliveries: []deliveryRow{{OrderUID: "b"}},
+			message:    "order a has no delivery",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := []*models.Order{{OrderUID: "a"}, {OrderUID: "b"}}
+
+			err := assembleOrders(orders, nil, tt.payments, tt.deliveries)
+
+			require.ErrorIs(t, err, ErrIncompleteOrder)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestAssembleOrders_UnexpectedRow(t *testing.T) {
+	err := assembleOrders([]*models.Order{{OrderUID: "a"}},
+		[]itemRow{{OrderUID: "z"}}, []paymentRow{{OrderUID: "a"}}, []deliveryRow{{OrderUID: "a"}})
+
+	assert.ErrorContains(t, err, "unexpected items row of order z")
+}
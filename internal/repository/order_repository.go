@@ -5,92 +5,102 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/metrics"
 	"order-service-wb/internal/models"
 )
 
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, order *models.Order) error
+	UpdateOrder(ctx context.Context, order *models.Order) error
+	UpdateOrderStatus(ctx context.Context, orderID string, from, to models.OrderStatus) error
 	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
 	GetAllOrders(ctx context.Context, limit int) ([]*models.Order, error)
+	SearchOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error)
 }
 
+const orderColumns = `o.order_uid, o.track_number, o.entry, o.locale,
+			o.internal_signature, o.customer_id, o.delivery_service,
+			o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version, o.status`
+
 type orderRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	logger *slog.Logger
 }
 
-func NewOrderRepository(db *sqlx.DB) OrderRepository {
+func NewOrderRepository(db *sqlx.DB, logger *slog.Logger) OrderRepository {
 	return &orderRepo{
-		db: db,
+		db:     db,
+		logger: logger,
 	}
 }
 
 func (r *orderRepo) CreateOrder(ctx context.Context, order *models.Order) error {
+	defer metrics.ObserveQuery("create_order", time.Now())
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		log.Println("failed to begin transaction:", err)
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return fmt.Errorf("failed to begin transaction: %w", dbError(err))
 	}
 	defer func() {
 		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			log.Println("failed to rollback transaction:", err)
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
 		}
 	}()
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error before execution:", err)
-		return fmt.Errorf("context cancelled before execution: %w", err)
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
 	}
 
 	q := `INSERT INTO orders(
             order_uid, track_number, entry, locale, 
         	internal_signature, customer_id, delivery_service,
-        	shardkey, sm_id, date_created, oof_shard)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        	shardkey, sm_id, date_created, oof_shard, version, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        ON CONFLICT (order_uid) DO NOTHING
 		`
 
-	_, err = tx.ExecContext(ctx, q,
+	res, err := tx.ExecContext(ctx, q,
 		order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
 		order.InternalSig, order.CustomerID, order.DeliverySrv,
 		order.ShardKey, order.SmID, order.DateCreated, order.OofShard,
+		order.Version, order.Status,
 	)
 
 	if err != nil {
-		log.Println("failed to execute insert order query:", err)
-		return fmt.Errorf("failed to insert order: %w", err)
+		r.logger.ErrorContext(ctx, "failed to execute insert order query", logging.Err(err))
+		return fmt.Errorf("failed to insert order: %w", dbError(err))
 	}
 
-	if err = ctx.Err(); err != nil {
-		log.Println("context error before execution:", err)
-		return fmt.Errorf("context cancelled before execution: %w", err)
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get affected rows for insert order query", logging.Err(err))
+		return fmt.Errorf("failed to insert order: %w", dbError(err))
+	}
+	if inserted == 0 {
+		return fmt.Errorf("order %s: %w", order.OrderUID, ErrOrderExists)
 	}
 
-	q = `INSERT INTO items(order_uid, chrt_id, 
-                  track_number, price, rid, name, sale, 
-                  size, total_price, nm_id, brand, status) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $9, $8, $10, $11, $12)
-		`
+	if err = r.insertStatusHistory(ctx, tx, order.OrderUID, "", order.Status); err != nil {
+		return err
+	}
 
-	for _, item := range order.Items {
+	if err = ctx.Err(); err != nil {
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
+	}
 
-		select {
-		case <-ctx.Done():
-			log.Println("context cancelled before executing items insert:", ctx.Err())
-			return fmt.Errorf("context cancelled before executing items insert: %w", ctx.Err())
-		default:
-			_, err = tx.ExecContext(ctx, q,
-				order.OrderUID, item.ChrtID, item.TrackNumber, item.Price,
-				item.Rid, item.Name, item.Sale, item.TotalPrice, item.Size,
-				item.NmID, item.Brand, item.Status,
-			)
-			if err != nil {
-				log.Println("failed to execute insert items query:", err)
-				return fmt.Errorf("failed to insert item: %w", err)
-			}
-		}
+	if err = r.insertItems(ctx, tx, order); err != nil {
+		return err
 	}
 
 	q = `INSERT INTO payment(order_uid, transaction, request_id, currency, 
@@ -105,13 +115,13 @@ func (r *orderRepo) CreateOrder(ctx context.Context, order *models.Order) error
 	)
 
 	if err != nil {
-		log.Println("failed to execute insert payment query:", err)
-		return fmt.Errorf("failed to insert payment: %w", err)
+		r.logger.ErrorContext(ctx, "failed to execute insert payment query", logging.Err(err))
+		return fmt.Errorf("failed to insert payment: %w", dbError(err))
 	}
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error before execution:", err)
-		return fmt.Errorf("context cancelled before execution: %w", err)
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
 	}
 
 	q = `INSERT INTO delivery(order_uid, name, phone, zip, city, address, region, email)
@@ -125,72 +135,298 @@ func (r *orderRepo) CreateOrder(ctx context.Context, order *models.Order) error
 	)
 
 	if err != nil {
-		log.Println("failed to execute insert delivery query:", err)
-		return fmt.Errorf("failed to insert delivery: %w", err)
+		r.logger.ErrorContext(ctx, "failed to execute insert delivery query", logging.Err(err))
+		return fmt.Errorf("failed to insert delivery: %w", dbError(err))
+	}
+
+	if err = ctx.Err(); err != nil {
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
+	}
+
+	if err = r.insertOutboxEvent(ctx, tx, order.OrderUID, models.EventOrderCreated, order); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return fmt.Errorf("failed to commit transaction: %w", dbError(err))
+	}
+
+	return nil
+}
+
+func (r *orderRepo) UpdateOrder(ctx context.Context, order *models.Order) error {
+	defer metrics.ObserveQuery("update_order", time.Now())
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return fmt.Errorf("failed to begin transaction: %w", dbError(err))
+	}
+	defer func() {
+		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	if err = ctx.Err(); err != nil {
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
+	}
+
+	q := `UPDATE orders SET
+			track_number = $2, entry = $3, locale = $4,
+			internal_signature = $5, customer_id = $6, delivery_service = $7,
+			shardkey = $8, sm_id = $9, date_created = $10, oof_shard = $11,
+			version = $12
+		WHERE order_uid = $1 AND version < $12
+		`
+
+	res, err := tx.ExecContext(ctx, q,
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
+		order.InternalSig, order.CustomerID, order.DeliverySrv,
+		order.ShardKey, order.SmID, order.DateCreated, order.OofShard,
+		order.Version,
+	)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute update order query", logging.Err(err))
+		return fmt.Errorf("failed to update order: %w", dbError(err))
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get affected rows for update order query", logging.Err(err))
+		return fmt.Errorf("failed to update order: %w", dbError(err))
+	}
+	if updated == 0 {
+		return fmt.Errorf("order %s version %d: %w", order.OrderUID, order.Version, ErrStaleVersion)
+	}
+
+	q = `DELETE FROM items WHERE order_uid = $1`
+	if _, err = tx.ExecContext(ctx, q, order.OrderUID); err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute delete items query", logging.Err(err))
+		return fmt.Errorf("failed to delete items: %w", dbError(err))
+	}
+
+	if err = r.insertItems(ctx, tx, order); err != nil {
+		return err
+	}
+
+	q = `UPDATE payment SET
+			transaction = $2, request_id = $3, currency = $4, provider = $5,
+			amount = $6, payment_dt = $7, bank = $8, delivery_cost = $9,
+			goods_total = $10, custom_fee = $11
+		WHERE order_uid = $1
+		`
+	res, err = tx.ExecContext(ctx, q,
+		order.OrderUID, order.Payment.Transaction, order.Payment.RequestID,
+		order.Payment.Currency, order.Payment.Provider, order.Payment.Amount,
+		order.Payment.PaymentDT, order.Payment.Bank, order.Payment.DeliveryCost,
+		order.Payment.GoodsTotal, order.Payment.CustomFee,
+	)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute update payment query", logging.Err(err))
+		return fmt.Errorf("failed to update payment: %w", dbError(err))
+	}
+
+	updated, err = res.RowsAffected()
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get affected rows for update payment query", logging.Err(err))
+		return fmt.Errorf("failed to update payment: %w", dbError(err))
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: order %s has no payment", ErrIncompleteOrder, order.OrderUID)
+	}
+
+	if err = ctx.Err(); err != nil {
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
+	}
+
+	q = `UPDATE delivery SET
+			name = $2, phone = $3, zip = $4, city = $5,
+			address = $6, region = $7, email = $8
+		WHERE order_uid = $1
+		`
+	res, err = tx.ExecContext(ctx, q,
+		order.OrderUID, order.Delivery.Name, order.Delivery.Phone,
+		order.Delivery.Zip, order.Delivery.City, order.Delivery.Addr,
+		order.Delivery.Region, order.Delivery.Email,
+	)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute update delivery query", logging.Err(err))
+		return fmt.Errorf("failed to update delivery: %w", dbError(err))
+	}
+
+	updated, err = res.RowsAffected()
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get affected rows for update delivery query", logging.Err(err))
+		return fmt.Errorf("failed to update delivery: %w", dbError(err))
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: order %s has no delivery", ErrIncompleteOrder, order.OrderUID)
 	}
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error before execution:", err)
-		return fmt.Errorf("context cancelled before execution: %w", err)
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return fmt.Errorf("context cancelled before execution: %w", dbError(err))
+	}
+
+	if err = r.insertOutboxEvent(ctx, tx, order.OrderUID, models.EventOrderUpdated, order); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return fmt.Errorf("failed to commit transaction: %w", dbError(err))
+	}
+
+	return nil
+}
+
+func (r *orderRepo) UpdateOrderStatus(ctx context.Context, orderID string, from, to models.OrderStatus) error {
+	defer metrics.ObserveQuery("update_order_status", time.Now())
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return fmt.Errorf("failed to begin transaction: %w", dbError(err))
+	}
+	defer func() {
+		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	q := `UPDATE orders SET status = $3 WHERE order_uid = $1 AND status = $2`
+
+	res, err := tx.ExecContext(ctx, q, orderID, from, to)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute update order status query", logging.Err(err))
+		return fmt.Errorf("failed to update order status: %w", dbError(err))
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get affected rows for update order status query", logging.Err(err))
+		return fmt.Errorf("failed to update order status: %w", dbError(err))
+	}
+	if updated == 0 {
+		return fmt.Errorf("order %s is no longer %s: %w", orderID, from, ErrStatusChanged)
+	}
+
+	if err = r.insertStatusHistory(ctx, tx, orderID, from, to); err != nil {
+		return err
+	}
+
+	change := models.StatusChange{From: from, To: to}
+	if err = r.insertOutboxEvent(ctx, tx, orderID, models.EventOrderStatusChanged, change); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
-		log.Println("failed to commit transaction:", err)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return fmt.Errorf("failed to commit transaction: %w", dbError(err))
+	}
+
+	return nil
+}
+
+func (r *orderRepo) insertStatusHistory(ctx context.Context, tx *sqlx.Tx, orderID string, from, to models.OrderStatus) error {
+	q := `INSERT INTO order_status_history(order_uid, from_status, to_status)
+		VALUES ($1, NULLIF($2, ''), $3)
+		`
+
+	if _, err := tx.ExecContext(ctx, q, orderID, from, to); err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute insert status history query", logging.Err(err))
+		return fmt.Errorf("failed to insert status history: %w", dbError(err))
+	}
+
+	return nil
+}
+
+func (r *orderRepo) insertItems(ctx context.Context, tx *sqlx.Tx, order *models.Order) error {
+	q := `INSERT INTO items(order_uid, chrt_id, 
+                  track_number, price, rid, name, sale, 
+                  size, total_price, nm_id, brand, status) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $9, $8, $10, $11, $12)
+		`
+
+	for _, item := range order.Items {
+
+		select {
+		case <-ctx.Done():
+			r.logger.ErrorContext(ctx, "context cancelled before executing items insert", logging.Err(ctx.Err()))
+			return fmt.Errorf("context cancelled before executing items insert: %w", dbError(ctx.Err()))
+		default:
+			_, err := tx.ExecContext(ctx, q,
+				order.OrderUID, item.ChrtID, item.TrackNumber, item.Price,
+				item.Rid, item.Name, item.Sale, item.TotalPrice, item.Size,
+				item.NmID, item.Brand, item.Status,
+			)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "failed to execute insert items query", logging.Err(err))
+				return fmt.Errorf("failed to insert item: %w", dbError(err))
+			}
+		}
 	}
 
 	return nil
 }
 
 func (r *orderRepo) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
+	defer metrics.ObserveQuery("get_order_by_id", time.Now())
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		log.Println("failed to begin transaction:", err)
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", dbError(err))
 	}
 	defer func() {
 		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			log.Println("failed to rollback transaction:", err)
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
 		}
 	}()
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error before execution:", err)
-		return nil, fmt.Errorf("context cancelled before execution: %w", err)
+		r.logger.ErrorContext(ctx, "context error before execution", logging.Err(err))
+		return nil, fmt.Errorf("context cancelled before execution: %w", dbError(err))
 	}
 
 	var order models.Order
 	q := `SELECT 
 			order_uid, track_number, entry, locale,
 			internal_signature, customer_id, delivery_service,
-			shardkey, sm_id, date_created, oof_shard
+			shardkey, sm_id, date_created, oof_shard, version, status
 		FROM orders WHERE order_uid = $1
 		`
 	err = tx.GetContext(ctx, &order, q, orderID)
 	if err != nil {
-		log.Println("failed to get order by ID:", err)
-		return nil, fmt.Errorf("failed to get order by ID: %w", err)
+		r.logger.ErrorContext(ctx, "failed to get order by ID", logging.Err(err))
+		return nil, fmt.Errorf("failed to get order by ID: %w", dbError(err))
 	}
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error after getting order:", err)
-		return nil, fmt.Errorf("context cancelled after getting order: %w", err)
+		r.logger.ErrorContext(ctx, "context error after getting order", logging.Err(err))
+		return nil, fmt.Errorf("context cancelled after getting order: %w", dbError(err))
 	}
 
 	q = `SELECT
 			chrt_id, track_number, price, rid, name, sale,
 			size, total_price, nm_id, brand, status
 		FROM items WHERE order_uid = $1
+		ORDER BY id
 		`
 	err = tx.SelectContext(ctx, &order.Items, q, orderID)
 	if err != nil {
-		log.Println("failed to get items for order:", err)
-		return nil, fmt.Errorf("failed to get items for order: %w", err)
+		r.logger.ErrorContext(ctx, "failed to get items for order", logging.Err(err))
+		return nil, fmt.Errorf("failed to get items for order: %w", dbError(err))
 	}
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error after getting items:", err)
-		return nil, fmt.Errorf("context cancelled after getting items: %w", err)
+		r.logger.ErrorContext(ctx, "context error after getting items", logging.Err(err))
+		return nil, fmt.Errorf("context cancelled after getting items: %w", dbError(err))
 	}
 
 	q = `SELECT
@@ -200,13 +436,13 @@ func (r *orderRepo) GetOrderByID(ctx context.Context, orderID string) (*models.O
 		`
 	err = tx.GetContext(ctx, &order.Payment, q, orderID)
 	if err != nil {
-		log.Println("failed to get payment for order:", err)
-		return nil, fmt.Errorf("failed to get payment for order: %w", err)
+		r.logger.ErrorContext(ctx, "failed to get payment for order", logging.Err(err))
+		return nil, fmt.Errorf("failed to get payment for order: %w", dbError(err))
 	}
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error after getting payment:", err)
-		return nil, fmt.Errorf("context cancelled after getting payment: %w", err)
+		r.logger.ErrorContext(ctx, "context error after getting payment", logging.Err(err))
+		return nil, fmt.Errorf("context cancelled after getting payment: %w", dbError(err))
 	}
 
 	q = `SELECT
@@ -215,43 +451,91 @@ func (r *orderRepo) GetOrderByID(ctx context.Context, orderID string) (*models.O
 		`
 	err = tx.GetContext(ctx, &order.Delivery, q, orderID)
 	if err != nil {
-		log.Println("failed to get delivery for order:", err)
-		return nil, fmt.Errorf("failed to get delivery for order: %w", err)
+		r.logger.ErrorContext(ctx, "failed to get delivery for order", logging.Err(err))
+		return nil, fmt.Errorf("failed to get delivery for order: %w", dbError(err))
 	}
 
 	if err = ctx.Err(); err != nil {
-		log.Println("context error after getting delivery:", err)
-		return nil, fmt.Errorf("context cancelled after getting delivery: %w", err)
+		r.logger.ErrorContext(ctx, "context error after getting delivery", logging.Err(err))
+		return nil, fmt.Errorf("context cancelled after getting delivery: %w", dbError(err))
 	}
 
 	if err = tx.Commit(); err != nil {
-		log.Println("failed to commit transaction:", err)
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", dbError(err))
 	}
 
 	return &order, nil
 }
 
 func (r *orderRepo) GetAllOrders(ctx context.Context, limit int) ([]*models.Order, error) {
-	q := `SELECT order_uid FROM orders ORDER BY date_created DESC LIMIT $1`
+	defer metrics.ObserveQuery("get_all_orders", time.Now())
 
-	rows, err := r.db.QueryxContext(ctx, q, limit)
-	if err != nil {
-		return nil, err
+	q := `SELECT ` + orderColumns + `
+		FROM orders o ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $1
+		`
+
+	return r.selectOrders(ctx, q, limit)
+}
+
+func (r *orderRepo) SearchOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	defer metrics.ObserveQuery("search_orders", time.Now())
+
+	q, args := buildSearchQuery(filter)
+
+	return r.selectOrders(ctx, q, args...)
+}
+
+func buildSearchQuery(filter models.OrderFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
 	}
-	defer rows.Close()
 
-	var orders []*models.Order
-	for rows.Next() {
-		var id string
-		if err = rows.Scan(&id); err != nil {
-			continue
-		}
-		order, err := r.GetOrderByID(ctx, id)
-		if err == nil {
-			orders = append(orders, order)
-		}
+	if filter.CustomerID != "" {
+		conds = append(conds, "o.customer_id = "+arg(filter.CustomerID))
+	}
+	if filter.TrackNumber != "" {
+		conds = append(conds, "o.track_number = "+arg(filter.TrackNumber))
+	}
+	if filter.DeliveryService != "" {
+		conds = append(conds, "o.delivery_service = "+arg(filter.DeliveryService))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conds = append(conds, "o.date_created >= "+arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		conds = append(conds, "o.date_created < "+arg(filter.CreatedTo))
+	}
+	if filter.PaymentProvider != "" {
+		conds = append(conds, "p.provider = "+arg(filter.PaymentProvider))
+	}
+	if filter.Currency != "" {
+		conds = append(conds, "p.currency = "+arg(filter.Currency))
+	}
+	if filter.ItemBrand != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.brand = "+arg(filter.ItemBrand)+")")
+	}
+	if filter.After != nil {
+		conds = append(conds, fmt.Sprintf("(o.date_created, o.order_uid) < (%s, %s)",
+			arg(filter.After.DateCreated), arg(filter.After.OrderUID)))
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + orderColumns + " FROM orders o")
+	if filter.PaymentProvider != "" || filter.Currency != "" {
+		b.WriteString(" JOIN payment p ON p.order_uid = o.order_uid")
+	}
+	if len(conds) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(conds, " AND "))
 	}
+	b.WriteString(" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT ")
+	b.WriteString(arg(filter.Limit))
 
-	return orders, nil
+	return b.String(), args
 }
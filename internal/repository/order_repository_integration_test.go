@@ -3,7 +3,9 @@ package repository_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"os"
 	"testing"
@@ -16,6 +18,7 @@ import (
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 
+	"order-service-wb/internal/apperrors"
 	"order-service-wb/internal/models"
 	"order-service-wb/internal/repository"
 )
@@ -67,7 +70,7 @@ func TestMain(m *testing.M) {
 
 func TestCreateAndGetOrder_Success(t *testing.T) {
 	dbx := sqlx.NewDb(db, "postgres")
-	repo := repository.NewOrderRepository(dbx)
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
 
 	testOrder := &models.Order{
 		OrderUID: "123",
@@ -83,7 +86,7 @@ func TestCreateAndGetOrder_Success(t *testing.T) {
 
 func TestGetAllOrders_Success(t *testing.T) {
 	dbx := sqlx.NewDb(db, "postgres")
-	repo := repository.NewOrderRepository(dbx)
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
 
 	order1 := generateFakeOrder("123")
 	order2 := generateFakeOrder("321")
@@ -112,7 +115,7 @@ func TestGetAllOrders_Success(t *testing.T) {
 
 func TestCreateOrder_Conflict(t *testing.T) {
 	dbx := sqlx.NewDb(db, "postgres")
-	repo := repository.NewOrderRepository(dbx)
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
 
 	order := generateFakeOrder("123")
 
@@ -120,15 +123,181 @@ func TestCreateOrder_Conflict(t *testing.T) {
 	require.NoError(t, err)
 
 	err = repo.CreateOrder(context.Background(), order)
-	require.Error(t, err)
+	require.ErrorIs(t, err, repository.ErrOrderExists)
+}
+
+func TestUpdateOrder_Versioned(t *testing.T) {
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
+
+	order := generateFakeOrder("456")
+
+	err := repo.CreateOrder(context.Background(), order)
+	require.NoError(t, err)
+
+	updated := *order
+	updated.Version = 2
+	updated.Delivery.Addr = "456 Other St"
+
+	err = repo.UpdateOrder(context.Background(), &updated)
+	require.NoError(t, err)
+
+	fetched, err := repo.GetOrderByID(context.Background(), "456")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), fetched.Version)
+	require.Equal(t, "456 Other St", fetched.Delivery.Addr)
+
+	err = repo.UpdateOrder(context.Background(), order)
+	require.ErrorIs(t, err, repository.ErrStaleVersion)
+}
+
+func TestUpdateOrder_MissingPayment(t *testing.T) {
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
+
+	order := generateFakeOrder(uuid.NewString())
+	require.NoError(t, repo.CreateOrder(context.Background(), order))
+	_, err := db.Exec(`DELETE FROM payment WHERE order_uid = $1`, order.OrderUID)
+	require.NoError(t, err)
+
+	updated := *order
+	updated.Version = 2
+	err = repo.UpdateOrder(context.Background(), &updated)
+	require.ErrorIs(t, err, repository.ErrIncompleteOrder)
+
+	var version int64
+	require.NoError(t, db.QueryRow(`SELECT version FROM orders WHERE order_uid = $1`, order.OrderUID).Scan(&version))
+	require.Equal(t, int64(1), version, "the update must be rolled back")
+}
+
+func TestSearchOrders_FilterAndPaginate(t *testing.T) {
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
+
+	for i, id := range []string{"search-1", "search-2", "search-3"} {
+		order := generateFakeOrder(id)
+		order.CustomerID = "search-customer"
+		order.DateCreated = time.Date(2025, 7, 1, i, 0, 0, 0, time.UTC)
+		require.NoError(t, repo.CreateOrder(context.Background(), order))
+	}
+
+	filter := models.OrderFilter{CustomerID: "search-customer", Currency: "USD", Limit: 2}
+	page, err := repo.SearchOrders(context.Background(), filter)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.Equal(t, "search-3", page[0].OrderUID)
+	require.Equal(t, "search-2", page[1].OrderUID)
+
+	filter.After = &models.OrderCursor{DateCreated: page[1].DateCreated, OrderUID: page[1].OrderUID}
+	page, err = repo.SearchOrders(context.Background(), filter)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, "search-1", page[0].OrderUID)
+}
+
+func TestRelayOutbox_PublishesAndDeletes(t *testing.T) {
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
+	outbox := repository.NewOutboxRepository(dbx, slog.New(slog.DiscardHandler))
+
+	order := generateFakeOrder("outbox-1")
+	require.NoError(t, repo.CreateOrder(context.Background(), order))
+	require.NoError(t, repo.UpdateOrderStatus(context.Background(), order.OrderUID, models.StatusCreated, models.StatusPaid))
+
+	var published []models.OrderEvent
+	publish := func(_ context.Context, events []models.OrderEvent) (int, error) {
+		published = append(published, events...)
+		return len(events), nil
+	}
+
+	_, err := outbox.RelayOutbox(context.Background(), 1000, publish)
+	require.NoError(t, err)
+
+	var types []models.EventType
+	for _, event := range published {
+		if event.OrderUID == order.OrderUID {
+			types = append(types, event.Type)
+		}
+	}
+	require.Equal(t, []models.EventType{models.EventOrderCreated, models.EventOrderStatusChanged}, types)
+
+	sent, err := outbox.RelayOutbox(context.Background(), 1000, publish)
+	require.NoError(t, err)
+	require.Zero(t, sent)
+}
+
+func TestRelayOutbox_PublishesWithoutHoldingLocks(t *testing.T) {
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
+	outbox := repository.NewOutboxRepository(dbx, slog.New(slog.DiscardHandler))
+
+	order := generateFakeOrder("outbox-2")
+	require.NoError(t, repo.CreateOrder(context.Background(), order))
+
+	errUnavailable := errors.New("broker unavailable")
+	_, err := outbox.RelayOutbox(context.Background(), 1000, func(ctx context.Context, events []models.OrderEvent) (int, error) {
+		var advisory int
+		require.NoError(t, db.QueryRow(`SELECT count(*) FROM pg_locks WHERE locktype = 'advisory'`).Scan(&advisory))
+		require.Zero(t, advisory, "the outbox lock must be released while publishing")
+
+		_, err := db.Exec(`SELECT id FROM outbox FOR UPDATE NOWAIT`)
+		require.NoError(t, err, "claimed rows must not stay locked while publishing")
+
+		sent, err := outbox.RelayOutbox(ctx, 1000, func(context.Context, []models.OrderEvent) (int, error) {
+			t.Error("a claimed batch must not be published by another relay")
+			return 0, nil
+		})
+		require.NoError(t, err)
+		require.Zero(t, sent)
+
+		return 0, errUnavailable
+	})
+	require.ErrorIs(t, err, errUnavailable)
+
+	var published []string
+	_, err = outbox.RelayOutbox(context.Background(), 1000, func(_ context.Context, events []models.OrderEvent) (int, error) {
+		for _, event := range events {
+			published = append(published, event.OrderUID)
+		}
+		return len(events), nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, published, order.OrderUID, "a failed batch is released for the next relay")
+}
+
+func TestSearchOrders_LoadsDetailsInBatch(t *testing.T) {
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
+
+	customer := "loader-" + randSeq(6)
+	order := generateFakeOrder(uuid.NewString())
+	order.CustomerID = customer
+	order.Items = append(order.Items, order.Items[0], order.Items[0])
+	for i := range order.Items {
+		order.Items[i].Rid = fmt.Sprintf("rid-%d", i)
+	}
+	require.NoError(t, repo.CreateOrder(context.Background(), order))
+
+	orders, err := repo.SearchOrders(context.Background(), models.OrderFilter{CustomerID: customer, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, order.Payment, orders[0].Payment)
+	require.Equal(t, order.Delivery, orders[0].Delivery)
+	require.Equal(t, order.Items, orders[0].Items)
+
+	_, err = db.Exec(`DELETE FROM payment WHERE order_uid = $1`, order.OrderUID)
+	require.NoError(t, err)
+
+	_, err = repo.SearchOrders(context.Background(), models.OrderFilter{CustomerID: customer, Limit: 10})
+	require.ErrorIs(t, err, repository.ErrIncompleteOrder)
 }
 
 func TestGetOrderByID_NotFound(t *testing.T) {
 	dbx := sqlx.NewDb(db, "postgres")
-	repo := repository.NewOrderRepository(dbx)
+	repo := repository.NewOrderRepository(dbx, slog.New(slog.DiscardHandler))
 
-	_, err := repo.GetOrderByID(context.Background(), "123")
-	require.Error(t, err)
+	_, err := repo.GetOrderByID(context.Background(), "missing")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
 }
 
 func generateFakeOrder(id string) *models.Order {
@@ -144,6 +313,8 @@ func generateFakeOrder(id string) *models.Order {
 		SmID:        rand.Intn(100),
 		DateCreated: time.Now().UTC(),
 		OofShard:    "1",
+		Version:     1,
+		Status:      models.StatusCreated,
 		Delivery: models.Delivery{
 			Name:   "Test User",
 			Phone:  "+1234567890",
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/metrics"
+	"order-service-wb/internal/models"
+)
+
+// outboxLockKey is the advisory lock serializing the claims of relays across
+// instances.
+const outboxLockKey = 7_153_420_001
+
+// outboxClaimTimeout bounds the publishing of a claimed batch. Until it
+// expires no other relay claims events, so that events of an order are never
+// published out of order; after that the batch is claimed again.
+const outboxClaimTimeout = time.Minute
+
+// PublishFunc publishes events in order and reports how many of the leading
+// events were sent, together with the error that stopped it, if any.
+type PublishFunc func(ctx context.Context, events []models.OrderEvent) (int, error)
+
+type OutboxRepository interface {
+	// RelayOutbox passes up to limit of the oldest outbox events to publish
+	// and deletes the ones it reports as sent. It returns the number of
+	// events relayed; zero without an error if another relay is publishing.
+	RelayOutbox(ctx context.Context, limit int, publish PublishFunc) (int, error)
+}
+
+type outboxRepo struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewOutboxRepository(db *sqlx.DB, logger *slog.Logger) OutboxRepository {
+	return &outboxRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type outboxRow struct {
+	ID         int64     `db:"id"`
+	Type       string    `db:"event_type"`
+	OrderUID   string    `db:"order_uid"`
+	Payload    []byte    `db:"payload"`
+	OccurredAt time.Time `db:"created_at"`
+}
+
+// RelayOutbox claims a batch in a short transaction, publishes it without
+// holding a transaction or locks, then deletes what was sent and releases the
+// rest. A relay that dies in between leaves its claim to expire, and the batch
+// is published again.
+func (r *outboxRepo) RelayOutbox(ctx context.Context, limit int, publish PublishFunc) (int, error) {
+	defer metrics.ObserveQuery("relay_outbox", time.Now())
+
+	events, err := r.claimOutbox(ctx, limit)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, outboxClaimTimeout)
+	sent, publishErr := publish(publishCtx, events)
+	cancel()
+
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	if err = r.finishOutbox(ctx, ids[:sent], ids[sent:]); err != nil {
+		return 0, errors.Join(err, publishErr)
+	}
+	return sent, publishErr
+}
+
+// claimOutbox marks up to limit of the oldest events as claimed and returns
+// them in order. It claims nothing while another claim is in force.
+func (r *outboxRepo) claimOutbox(ctx context.Context, limit int) ([]models.OrderEvent, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", dbError(err))
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	var locked bool
+	if err = tx.GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey); err != nil {
+		r.logger.ErrorContext(ctx, "failed to lock outbox", logging.Err(err))
+		return nil, fmt.Errorf("failed to lock outbox: %w", dbError(err))
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	var claimed bool
+	if err = tx.GetContext(ctx, &claimed, `SELECT EXISTS(SELECT 1 FROM outbox WHERE claimed_until > now())`); err != nil {
+		r.logger.ErrorContext(ctx, "failed to check outbox claims", logging.Err(err))
+		return nil, fmt.Errorf("failed to check outbox claims: %w", dbError(err))
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	var rows []outboxRow
+	q := `UPDATE outbox SET claimed_until = now() + make_interval(secs => $2)
+		WHERE id IN (SELECT id FROM outbox ORDER BY id LIMIT $1)
+		RETURNING id, event_type, order_uid, payload, created_at
+		`
+	if err = tx.SelectContext(ctx, &rows, q, limit, outboxClaimTimeout.Seconds()); err != nil {
+		r.logger.ErrorContext(ctx, "failed to claim outbox events", logging.Err(err))
+		return nil, fmt.Errorf("failed to claim outbox events: %w", dbError(err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", dbError(err))
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	slices.SortFunc(rows, func(a, b outboxRow) int { return cmp.Compare(a.ID, b.ID) })
+
+	events := make([]models.OrderEvent, len(rows))
+	for i, row := range rows {
+		events[i] = models.OrderEvent{
+			ID:         row.ID,
+			Type:       models.EventType(row.Type),
+			OrderUID:   row.OrderUID,
+			OccurredAt: row.OccurredAt,
+			Payload:    row.Payload,
+		}
+	}
+	return events, nil
+}
+
+// finishOutbox deletes the sent events of a claimed batch and releases the
+// claim on the others, so that the next relay retries them right away.
+func (r *outboxRepo) finishOutbox(ctx context.Context, sent, unsent []int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to begin transaction", logging.Err(err))
+		return fmt.Errorf("failed to begin transaction: %w", dbError(err))
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.logger.ErrorContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM outbox WHERE id = ANY($1)`, pq.Array(sent)); err != nil {
+		r.logger.ErrorContext(ctx, "failed to delete outbox events", logging.Err(err))
+		return fmt.Errorf("failed to delete outbox events: %w", dbError(err))
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE outbox SET claimed_until = NULL WHERE id = ANY($1)`, pq.Array(unsent)); err != nil {
+		r.logger.ErrorContext(ctx, "failed to release outbox events", logging.Err(err))
+		return fmt.Errorf("failed to release outbox events: %w", dbError(err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.ErrorContext(ctx, "failed to commit transaction", logging.Err(err))
+		return fmt.Errorf("failed to commit transaction: %w", dbError(err))
+	}
+	return nil
+}
+
+func (r *orderRepo) insertOutboxEvent(ctx context.Context, tx *sqlx.Tx, orderID string, eventType models.EventType, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	q := `INSERT INTO outbox(order_uid, event_type, payload) VALUES ($1, $2, $3)`
+	if _, err = tx.ExecContext(ctx, q, orderID, eventType, data); err != nil {
+		r.logger.ErrorContext(ctx, "failed to execute insert outbox event query", logging.Err(err))
+		return fmt.Errorf("failed to insert outbox event: %w", dbError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"fmt"
+
+	"order-service-wb/internal/models"
+)
+
+var registry = map[string]Rule{}
+
+// Register makes a rule available to NewEngine under its name. It is meant
+// to be called from init functions and panics on duplicate names.
+func Register(rule Rule) {
+	if _, ok := registry[rule.Name()]; ok {
+		panic("rules: duplicate rule " + rule.Name())
+	}
+	registry[rule.Name()] = rule
+}
+
+func init() {
+	Register(ruleFunc{"goods_total", checkGoodsTotal})
+	Register(ruleFunc{"amount", checkAmount})
+	Register(ruleFunc{"item_total_price", checkItemTotalPrice})
+	Register(ruleFunc{"transaction_matches_order", checkTransaction})
+}
+
+type ruleFunc struct {
+	name  string
+	check func(order *models.Order) []Violation
+}
+
+func (r ruleFunc) Name() string {
+	return r.name
+}
+
+func (r ruleFunc) Check(order *models.Order) []Violation {
+	violations := r.check(order)
+	for i := range violations {
+		violations[i].Rule = r.name
+	}
+	return violations
+}
+
+// checkGoodsTotal: payment.goods_total is the sum of items[].total_price.
+func checkGoodsTotal(order *models.Order) []Violation {
+	sum := 0
+	for _, item := range order.Items {
+		sum += item.TotalPrice
+	}
+	if order.Payment.GoodsTotal == sum {
+		return nil
+	}
+	return []Violation{{
+		Field:   "payment.goods_total",
+		Message: fmt.Sprintf("expected %d (sum of item total prices), got %d", sum, order.Payment.GoodsTotal),
+	}}
+}
+
+// checkAmount: payment.amount is goods_total + delivery_cost + custom_fee.
+func checkAmount(order *models.Order) []Violation {
+	p := order.Payment
+	expected := p.GoodsTotal + p.DeliveryCost + p.CustomFee
+	if p.Amount == expected {
+		return nil
+	}
+	return []Violation{{
+		Field:   "payment.amount",
+		Message: fmt.Sprintf("expected %d (goods_total + delivery_cost + custom_fee), got %d", expected, p.Amount),
+	}}
+}
+
+// checkItemTotalPrice: items[].total_price is price reduced by sale percent.
+func checkItemTotalPrice(order *models.Order) []Violation {
+	var violations []Violation
+	for i, item := range order.Items {
+		expected := item.Price * (100 - item.Sale) / 100
+		if item.TotalPrice != expected {
+			violations = append(violations, Violation{
+				Field:   fmt.Sprintf("items[%d].total_price", i),
+				Message: fmt.Sprintf("expected %d (price %d with %d%% sale), got %d", expected, item.Price, item.Sale, item.TotalPrice),
+			})
+		}
+	}
+	return violations
+}
+
+// checkTransaction: payment.transaction references the order it pays for.
+func checkTransaction(order *models.Order) []Violation {
+	if order.Payment.Transaction == order.OrderUID {
+		return nil
+	}
+	return []Violation{{
+		Field:   "payment.transaction",
+		Message: fmt.Sprintf("expected %q (order_uid), got %q", order.OrderUID, order.Payment.Transaction),
+	}}
+}
@@ -0,0 +1,123 @@
+// Package rules implements cross-field business validation of orders on top
+// of the struct tag checks done by the validator.
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/models"
+)
+
+type Mode string
+
+const (
+	// ModeReject fails the order when the rule is violated.
+	ModeReject Mode = "reject"
+	// ModeWarn only reports the violation to the caller.
+	ModeWarn Mode = "warn"
+)
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Rule interface {
+	Name() string
+	Check(order *models.Order) []Violation
+}
+
+type Setting struct {
+	Enabled bool
+	Mode    Mode
+}
+
+// ViolationError is returned by Engine.Validate when rules in reject mode
+// are violated.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", v.Field, v.Message))
+	}
+	return "business rules violated: " + strings.Join(msgs, "; ")
+}
+
+func (e *ViolationError) Is(target error) bool {
+	return target == apperrors.ErrValidation
+}
+
+type enabledRule struct {
+	rule Rule
+	mode Mode
+}
+
+type Engine struct {
+	rules []enabledRule
+}
+
+// NewEngine enables the registered rules listed in settings. Rules missing from
+// settings stay disabled. An empty mode defaults to ModeReject.
+func NewEngine(settings map[string]Setting) (*Engine, error) {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e := &Engine{}
+	for _, name := range names {
+		setting := settings[name]
+		rule, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown business rule %q", name)
+		}
+		if !setting.Enabled {
+			continue
+		}
+
+		mode := setting.Mode
+		switch mode {
+		case "":
+			mode = ModeReject
+		case ModeReject, ModeWarn:
+		default:
+			return nil, fmt.Errorf("business rule %q: unknown mode %q", name, mode)
+		}
+
+		e.rules = append(e.rules, enabledRule{rule: rule, mode: mode})
+	}
+
+	return e, nil
+}
+
+// Validate runs the enabled rules. Violations of warn-mode rules are returned
+// as warnings; violations of reject-mode rules are returned as a
+// *ViolationError. A nil Engine accepts every order.
+func (e *Engine) Validate(order *models.Order) (warnings []Violation, err error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	var rejected []Violation
+	for _, r := range e.rules {
+		violations := r.rule.Check(order)
+		if r.mode == ModeWarn {
+			warnings = append(warnings, violations...)
+		} else {
+			rejected = append(rejected, violations...)
+		}
+	}
+
+	if len(rejected) > 0 {
+		return warnings, &ViolationError{Violations: rejected}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,101 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/rules"
+)
+
+func consistentOrder() *models.Order {
+	return &models.Order{
+		OrderUID: "123",
+		Payment: models.Payment{
+			Transaction:  "123",
+			Amount:       1817,
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []models.Item{
+			{Price: 453, Sale: 30, TotalPrice: 317},
+		},
+	}
+}
+
+func allRules(mode rules.Mode) map[string]rules.Setting {
+	return map[string]rules.Setting{
+		"goods_total":               {Enabled: true, Mode: mode},
+		"amount":                    {Enabled: true, Mode: mode},
+		"item_total_price":          {Enabled: true, Mode: mode},
+		"transaction_matches_order": {Enabled: true, Mode: mode},
+	}
+}
+
+func TestEngine_AcceptsConsistentOrder(t *testing.T) {
+	t.Parallel()
+
+	engine, err := rules.NewEngine(allRules(rules.ModeReject))
+	require.NoError(t, err)
+
+	warnings, err := engine.Validate(consistentOrder())
+
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestEngine_RejectsInconsistentOrder(t *testing.T) {
+	t.Parallel()
+
+	engine, err := rules.NewEngine(allRules(rules.ModeReject))
+	require.NoError(t, err)
+
+	order := consistentOrder()
+	order.Items[0].TotalPrice = 300
+	order.Payment.Transaction = "other"
+
+	_, err = engine.Validate(order)
+
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+
+	var violationErr *rules.ViolationError
+	require.ErrorAs(t, err, &violationErr)
+
+	fields := make([]string, 0, len(violationErr.Violations))
+	for _, v := range violationErr.Violations {
+		fields = append(fields, v.Field)
+	}
+	assert.ElementsMatch(t, []string{"payment.goods_total", "items[0].total_price", "payment.transaction"}, fields)
+}
+
+func TestEngine_WarnModeDoesNotReject(t *testing.T) {
+	t.Parallel()
+
+	settings := allRules(rules.ModeReject)
+	settings["amount"] = rules.Setting{Enabled: true, Mode: rules.ModeWarn}
+	settings["goods_total"] = rules.Setting{Enabled: false}
+
+	engine, err := rules.NewEngine(settings)
+	require.NoError(t, err)
+
+	order := consistentOrder()
+	order.Payment.Amount = 1
+	order.Payment.GoodsTotal = 2
+
+	warnings, err := engine.Validate(order)
+
+	assert.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "amount", warnings[0].Rule)
+}
+
+func TestNewEngine_UnknownRule(t *testing.T) {
+	t.Parallel()
+
+	_, err := rules.NewEngine(map[string]rules.Setting{"no_such_rule": {Enabled: true}})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+)
+
+// manifestFile indexes the schemas of a FileRegistry directory. Each version
+// is kept in its own file next to it, as <subject>/v<version>.avsc.
+const manifestFile = "registry.json"
+
+var subjectName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+type manifest struct {
+	Subjects map[string]subjectConfig `json:"subjects"`
+	Schemas  []manifestEntry          `json:"schemas"`
+}
+
+type subjectConfig struct {
+	Compatibility Compatibility `json:"compatibility"`
+}
+
+type manifestEntry struct {
+	ID      int    `json:"id"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	File    string `json:"file"`
+}
+
+// FileRegistry is a Registry kept in a directory, so that schemas are
+// reviewed and versioned together with the code. Subjects without an explicit
+// compatibility use CompatibilityBackward.
+type FileRegistry struct {
+	dir string
+
+	mu       sync.RWMutex
+	manifest manifest
+	byID     map[int]Schema
+	latest   map[string]Schema
+}
+
+// OpenFileRegistry loads the registry in dir. A missing manifest is an empty
+// registry.
+func OpenFileRegistry(dir string) (*FileRegistry, error) {
+	r := &FileRegistry{
+		dir:      dir,
+		manifest: manifest{Subjects: map[string]subjectConfig{}},
+		byID:     map[int]Schema{},
+		latest:   map[string]Schema{},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema registry: %w", err)
+	}
+	if err = json.Unmarshal(data, &r.manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode schema registry: %w", err)
+	}
+	if r.manifest.Subjects == nil {
+		r.manifest.Subjects = map[string]subjectConfig{}
+	}
+
+	for _, entry := range r.manifest.Schemas {
+		definition, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %d: %w", entry.ID, err)
+		}
+		parsed, err := avro.ParseBytes(definition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema %d: %w", entry.ID, err)
+		}
+		r.add(Schema{ID: entry.ID, Subject: entry.Subject, Version: entry.Version, Avro: parsed})
+	}
+
+	return r, nil
+}
+
+func (r *FileRegistry) ByID(id int) (Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.byID[id]
+	if !ok {
+		return Schema{}, fmt.Errorf("%w: id %d", ErrNotFound, id)
+	}
+	return s, nil
+}
+
+func (r *FileRegistry) Latest(subject string) (Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.latest[subject]
+	if !ok {
+		return Schema{}, fmt.Errorf("%w: subject %q", ErrNotFound, subject)
+	}
+	return s, nil
+}
+
+func (r *FileRegistry) Check(subject, definition string) error {
+	parsed, err := avro.Parse(definition)
+	if err != nil {
+		return fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, err = r.check(subject, parsed)
+	return err
+}
+
+func (r *FileRegistry) Register(subject, definition string) (Schema, error) {
+	if !subjectName.MatchString(subject) {
+		return Schema{}, fmt.Errorf("invalid subject %q", subject)
+	}
+	parsed, err := avro.Parse(definition)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	latest, err := r.check(subject, parsed)
+	if err != nil {
+		return Schema{}, err
+	}
+	if latest.Avro != nil && latest.Avro.Fingerprint() == parsed.Fingerprint() {
+		return latest, nil
+	}
+
+	s := Schema{ID: r.nextID(), Subject: subject, Version: latest.Version + 1, Avro: parsed}
+	entry := manifestEntry{
+		ID:      s.ID,
+		Subject: subject,
+		Version: s.Version,
+		File:    filepath.ToSlash(filepath.Join(subject, fmt.Sprintf("v%d.avsc", s.Version))),
+	}
+
+	path := filepath.Join(r.dir, entry.File)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Schema{}, fmt.Errorf("failed to create subject directory: %w", err)
+	}
+	if err = os.WriteFile(path, []byte(definition), 0o644); err != nil {
+		return Schema{}, fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	next := r.manifest
+	next.Schemas = append(next.Schemas[:len(next.Schemas):len(next.Schemas)], entry)
+	if err = r.writeManifest(next); err != nil {
+		_ = os.Remove(path)
+		return Schema{}, err
+	}
+
+	r.manifest = next
+	r.add(s)
+	return s, nil
+}
+
+// check returns the latest version of subject, or a zero Schema if there is
+// none, after checking that s may follow it.
+func (r *FileRegistry) check(subject string, s avro.Schema) (Schema, error) {
+	latest, ok := r.latest[subject]
+	if !ok {
+		return Schema{}, nil
+	}
+
+	mode := r.manifest.Subjects[subject].Compatibility
+	if mode == "" {
+		mode = CompatibilityBackward
+	}
+	if err := CheckCompatibility(mode, latest.Avro, s); err != nil {
+		return Schema{}, fmt.Errorf("subject %q version %d: %w", subject, latest.Version, err)
+	}
+	return latest, nil
+}
+
+func (r *FileRegistry) add(s Schema) {
+	r.byID[s.ID] = s
+	if latest, ok := r.latest[s.Subject]; !ok || s.Version > latest.Version {
+		r.latest[s.Subject] = s
+	}
+}
+
+func (r *FileRegistry) nextID() int {
+	id := 0
+	for existing := range r.byID {
+		id = max(id, existing)
+	}
+	return id + 1
+}
+
+// writeManifest replaces the manifest atomically so that a failed write never
+// leaves a registry that cannot be opened.
+func (r *FileRegistry) writeManifest(m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema registry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(r.dir, manifestFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	if _, err = tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(r.dir, manifestFile)); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package schema_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-service-wb/internal/schema"
+)
+
+const (
+	userV1 = `{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "string"}
+	]}`
+	// userV2 adds a field with a default: old data is still readable.
+	userV2 = `{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "string"},
+		{"name": "email", "type": "string", "default": ""}
+	]}`
+	// userV3 adds a field without a default, which data written with v2
+	// cannot fill in.
+	userV3 = `{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "string"},
+		{"name": "email", "type": "string", "default": ""},
+		{"name": "age", "type": "int"}
+	]}`
+)
+
+func TestFileRegistry_Register(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := schema.OpenFileRegistry(dir)
+	require.NoError(t, err)
+
+	v1, err := registry.Register("user", userV1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v1.ID)
+	assert.Equal(t, 1, v1.Version)
+
+	again, err := registry.Register("user", userV1)
+	require.NoError(t, err)
+	assert.Equal(t, v1.ID, again.ID, "identical schema must not create a version")
+
+	v2, err := registry.Register("user", userV2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v2.ID)
+	assert.Equal(t, 2, v2.Version)
+
+	_, err = registry.Register("user", userV3)
+	require.ErrorIs(t, err, schema.ErrIncompatible)
+	require.ErrorIs(t, registry.Check("user", userV3), schema.ErrIncompatible)
+	assert.NoFileExists(t, filepath.Join(dir, "user", "v3.avsc"))
+
+	reopened, err := schema.OpenFileRegistry(dir)
+	require.NoError(t, err)
+	latest, err := reopened.Latest("user")
+	require.NoError(t, err)
+	assert.Equal(t, v2.ID, latest.ID)
+	first, err := reopened.ByID(v1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, v1.Avro.Fingerprint(), first.Avro.Fingerprint())
+
+	_, err = reopened.ByID(42)
+	assert.ErrorIs(t, err, schema.ErrNotFound)
+}
+
+func TestFileRegistry_SubjectCompatibility(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `{"subjects": {"user": {"compatibility": "FORWARD"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "registry.json"), []byte(manifest), 0o644))
+
+	registry, err := schema.OpenFileRegistry(dir)
+	require.NoError(t, err)
+
+	_, err = registry.Register("user", userV2)
+	require.NoError(t, err)
+
+	// Removing a field with a default keeps forward compatibility: readers on
+	// v2 fill it in from the default.
+	_, err = registry.Register("user", userV1)
+	require.NoError(t, err)
+
+	_, err = registry.Register("../user", userV1)
+	assert.Error(t, err)
+}
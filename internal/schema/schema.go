@@ -0,0 +1,87 @@
+// Package schema keeps versioned Avro schemas of the message payloads and
+// checks that a new version stays compatible with the previous one.
+package schema
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hamba/avro/v2"
+)
+
+// Compatibility is the rule a new version of a subject must satisfy against
+// its latest version, named as in the Confluent schema registry.
+type Compatibility string
+
+const (
+	CompatibilityNone Compatibility = "NONE"
+	// CompatibilityBackward lets consumers on the new version read data
+	// written with the previous one. Consumers are upgraded first.
+	CompatibilityBackward Compatibility = "BACKWARD"
+	// CompatibilityForward lets consumers on the previous version read data
+	// written with the new one. Producers are upgraded first.
+	CompatibilityForward Compatibility = "FORWARD"
+	// CompatibilityFull is both backward and forward.
+	CompatibilityFull Compatibility = "FULL"
+)
+
+var (
+	ErrNotFound     = errors.New("schema not found")
+	ErrIncompatible = errors.New("incompatible schema")
+)
+
+type Schema struct {
+	ID      int
+	Subject string
+	Version int
+	Avro    avro.Schema
+}
+
+type Registry interface {
+	// Register adds definition as the next version of subject unless it is
+	// identical to the latest one, in which case the latest is returned.
+	Register(subject, definition string) (Schema, error)
+	// Check reports whether definition could be registered for subject.
+	Check(subject, definition string) error
+	ByID(id int) (Schema, error)
+	Latest(subject string) (Schema, error)
+}
+
+var compatibility = avro.NewSchemaCompatibility()
+
+// CheckCompatibility reports whether next may follow previous under mode.
+func CheckCompatibility(mode Compatibility, previous, next avro.Schema) error {
+	var err error
+	switch mode {
+	case CompatibilityNone:
+		return nil
+	case CompatibilityBackward:
+		err = compatibility.Compatible(next, previous)
+	case CompatibilityForward:
+		err = compatibility.Compatible(previous, next)
+	case CompatibilityFull:
+		if err = compatibility.Compatible(next, previous); err == nil {
+			err = compatibility.Compatible(previous, next)
+		}
+	default:
+		return fmt.Errorf("unknown compatibility %q", mode)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrIncompatible, mode, err)
+	}
+	return nil
+}
+
+// Resolve returns a schema that decodes data written with writer into values
+// shaped by reader.
+func Resolve(reader, writer avro.Schema) (avro.Schema, error) {
+	if reader.Fingerprint() == writer.Fingerprint() {
+		return reader, nil
+	}
+	resolved, err := compatibility.Resolve(reader, writer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncompatible, err)
+	}
+	return resolved, nil
+}
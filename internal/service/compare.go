@@ -0,0 +1,25 @@
+package service
+
+import (
+	"reflect"
+	"time"
+
+	"order-service-wb/internal/models"
+)
+
+// sameOrder reports whether two orders carry the same payload. Timestamps are
+// compared at the precision Postgres stores them with, regardless of zone.
+// Status is managed by the lifecycle and is not part of the payload.
+func sameOrder(a, b *models.Order) bool {
+	return reflect.DeepEqual(normalizeOrder(a), normalizeOrder(b))
+}
+
+func normalizeOrder(order *models.Order) models.Order {
+	normalized := *order
+	normalized.DateCreated = order.DateCreated.UTC().Truncate(time.Microsecond)
+	normalized.Status = ""
+	if len(order.Items) == 0 {
+		normalized.Items = nil
+	}
+	return normalized
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+
+	"order-service-wb/internal/apperrors"
+	"order-service-wb/internal/models"
+)
+
+// OrderConflictError is returned when an order is received under an
+// order_uid that is already stored with a different payload.
+type OrderConflictError struct {
+	OrderUID string
+}
+
+func (e *OrderConflictError) Error() string {
+	return fmt.Sprintf("order %s already exists with a different payload", e.OrderUID)
+}
+
+func (e *OrderConflictError) Is(target error) bool {
+	return target == apperrors.ErrConflict
+}
+
+// StaleVersionError is returned when an order update carries a version that
+// is not newer than the stored one, e.g. because it was delivered out of order.
+type StaleVersionError struct {
+	OrderUID string
+	Version  int64
+}
+
+func (e *StaleVersionError) Error() string {
+	return fmt.Sprintf("order %s: version %d is not newer than the stored one", e.OrderUID, e.Version)
+}
+
+func (e *StaleVersionError) Is(target error) bool {
+	return target == apperrors.ErrConflict
+}
+
+type UnknownStatusError struct {
+	Status models.OrderStatus
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown order status %q", e.Status)
+}
+
+func (e *UnknownStatusError) Is(target error) bool {
+	return target == apperrors.ErrValidation
+}
+
+// InvalidTransitionError is returned when an order cannot move from its
+// current status to the requested one. Concurrent is set when the order
+// changed status between reading and updating it.
+type InvalidTransitionError struct {
+	OrderUID   string
+	From       models.OrderStatus
+	To         models.OrderStatus
+	Concurrent bool
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if e.Concurrent {
+		return fmt.Sprintf("order %s changed status concurrently, expected %s", e.OrderUID, e.From)
+	}
+	return fmt.Sprintf("order %s cannot move from %s to %s", e.OrderUID, e.From, e.To)
+}
+
+func (e *InvalidTransitionError) Is(target error) bool {
+	return target == apperrors.ErrConflict
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"order-service-wb/internal/models"
+)
+
+// maxNotFoundEntries bounds the negative cache so that scanners hitting random
+// order IDs cannot grow it without limit.
+const maxNotFoundEntries = 10_000
+
+type loadCall struct {
+	done  chan struct{}
+	order *models.Order
+	err   error
+	// stale is set when the order is written while the load is in flight. The
+	// result is still returned to the callers waiting for it, but not stored.
+	stale bool
+}
+
+// loadGroup collapses concurrent loads of the same order into one call. The
+// load runs detached from the callers' contexts so that one caller giving up
+// does not fail the others; each caller stops waiting when its own context is
+// done.
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+func newLoadGroup() *loadGroup {
+	return &loadGroup{calls: make(map[string]*loadCall)}
+}
+
+// do loads the order through load and passes the result to store unless the
+// order was invalidated in the meantime. store runs under the group lock, so
+// that it cannot overwrite what a write made after it stored.
+func (g *loadGroup) do(ctx context.Context, id string,
+	load func(ctx context.Context) (*models.Order, error),
+	store func(ctx context.Context, order *models.Order, err error),
+) (*models.Order, error) {
+	g.mu.Lock()
+	call, ok := g.calls[id]
+	if !ok {
+		call = &loadCall{done: make(chan struct{})}
+		g.calls[id] = call
+
+		go func() {
+			loadCtx := context.WithoutCancel(ctx)
+			order, err := load(loadCtx)
+
+			g.mu.Lock()
+			if !call.stale {
+				store(loadCtx, order, err)
+				delete(g.calls, id)
+			}
+			call.order, call.err = order, err
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+	// Waiters share the loaded order; hand each of them its own copy.
+	order := *call.order
+	return &order, nil
+}
+
+// invalidate keeps the load of id in flight, if any, from storing its result
+// and makes later callers start a new one. Writers call it after writing the
+// order and before updating the cache.
+func (g *loadGroup) invalidate(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if call, ok := g.calls[id]; ok {
+		call.stale = true
+		delete(g.calls, id)
+	}
+}
+
+// notFoundCache remembers order IDs that were recently looked up and missing.
+type notFoundCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+func newNotFoundCache(ttl time.Duration) *notFoundCache {
+	return &notFoundCache{
+		ttl:     ttl,
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (c *notFoundCache) contains(id string) bool {
+	if c.ttl <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt, ok := c.entries[id]
+	if !ok {
+		return false
+	}
+	if !c.now().Before(expiresAt) {
+		delete(c.entries, id)
+		return false
+	}
+	return true
+}
+
+func (c *notFoundCache) add(id string) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxNotFoundEntries {
+		for key, expiresAt := range c.entries {
+			if !now.Before(expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxNotFoundEntries {
+			return
+		}
+	}
+	c.entries[id] = now.Add(c.ttl)
+}
+
+func (c *notFoundCache) remove(id string) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
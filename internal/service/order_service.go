@@ -2,48 +2,114 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 
+	"order-service-wb/internal/apperrors"
 	"order-service-wb/internal/cache"
+	"order-service-wb/internal/logging"
 	"order-service-wb/internal/models"
 	"order-service-wb/internal/repository"
+	"order-service-wb/internal/rules"
+	"order-service-wb/internal/tracing"
 )
 
 type OrderService interface {
 	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
 	LoadCache(ctx context.Context, limit int) error
 	CreateOrder(ctx context.Context, order *models.Order) error
+	ValidateOrder(ctx context.Context, order *models.Order) error
+	ChangeOrderStatus(ctx context.Context, orderID string, status models.OrderStatus) (*models.Order, error)
+	SearchOrders(ctx context.Context, filter models.OrderFilter) (*models.OrderPage, error)
+	CacheStats() cache.Stats
+	EvictFromCache(orderID string)
+	ReloadCache(ctx context.Context) error
 }
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
 type Service struct {
 	repo      repository.OrderRepository
 	cache     cache.Cache
 	validator *validator.Validate
+	rules     *rules.Engine
+	loads     *loadGroup
+	notFound  *notFoundCache
+	logger    *slog.Logger
+	// warmLimit remembers the limit of the last LoadCache call so that
+	// ReloadCache can repeat the warm-up.
+	warmLimit atomic.Int64
 }
 
-func NewOrderService(repo repository.OrderRepository, cache cache.Cache) OrderService {
+// NewOrderService creates the order service. rules may be nil to skip
+// business-rule validation. Lookups of missing orders are remembered for
+// notFoundTTL; zero disables negative caching.
+func NewOrderService(repo repository.OrderRepository, cache cache.Cache, rules *rules.Engine, notFoundTTL time.Duration, logger *slog.Logger) OrderService {
 	return &Service{
 		repo:      repo,
 		cache:     cache,
-		validator: validator.New(),
+		validator: newValidator(),
+		rules:     rules,
+		loads:     newLoadGroup(),
+		notFound:  newNotFoundCache(notFoundTTL),
+		logger:    logger,
 	}
 }
 
-func (s *Service) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
-	if order, ok := s.cache.Get(orderID); ok {
+// newValidator reports field paths using JSON names, e.g. "items[0].price".
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+func (s *Service) GetOrderByID(ctx context.Context, orderID string) (_ *models.Order, err error) {
+	ctx, span := startSpan(ctx, "GetOrderByID", orderID)
+	defer tracing.End(span, &err)
+
+	if order, ok := s.cacheGet(ctx, orderID); ok {
 		return &order, nil
 	}
 
-	order, err := s.repo.GetOrderByID(ctx, orderID)
-	if err == nil && order != nil {
-		s.cache.Set(orderID, *order)
+	if s.notFound.contains(orderID) {
+		return nil, fmt.Errorf("order %s: %w", orderID, apperrors.ErrNotFound)
 	}
 
-	return order, err
+	ctx = logging.With(ctx, logging.KeyOrderUID, orderID)
+
+	return s.loads.do(ctx, orderID, func(ctx context.Context) (*models.Order, error) {
+		return s.repo.GetOrderByID(ctx, orderID)
+	}, func(ctx context.Context, order *models.Order, err error) {
+		switch {
+		case err == nil:
+			s.cacheSet(ctx, order)
+		case errors.Is(err, apperrors.ErrNotFound):
+			s.notFound.add(orderID)
+		}
+	})
 }
 
-func (s *Service) LoadCache(ctx context.Context, limit int) error {
+func (s *Service) LoadCache(ctx context.Context, limit int) (err error) {
+	ctx, span := startSpan(ctx, "LoadCache", "")
+	defer tracing.End(span, &err)
+
+	s.warmLimit.Store(int64(limit))
 	orders, err := s.repo.GetAllOrders(ctx, limit)
 	if err != nil {
 		return err
@@ -51,16 +117,147 @@ func (s *Service) LoadCache(ctx context.Context, limit int) error {
 	for _, order := range orders {
 		s.cache.Set(order.OrderUID, *order)
 	}
+	s.logger.InfoContext(ctx, "cache warmed up", "orders", len(orders))
 	return nil
 }
 
-func (s *Service) CreateOrder(ctx context.Context, order *models.Order) error {
-	if err := s.validator.Struct(order); err != nil {
+// CreateOrder stores a new order or applies an update to an existing one.
+// A redelivery of the stored version is a no-op, a higher version replaces
+// the stored order and a lower one is rejected with StaleVersionError. The
+// status of the payload is ignored: new orders always start as created and
+// later statuses are only reached through ChangeOrderStatus.
+func (s *Service) CreateOrder(ctx context.Context, order *models.Order) (err error) {
+	ctx, span := startSpan(ctx, "CreateOrder", order.OrderUID)
+	defer tracing.End(span, &err)
+	ctx = logging.With(ctx, logging.KeyOrderUID, order.OrderUID)
+
+	if err = s.validate(ctx, order); err != nil {
 		return err
 	}
-	if err := s.repo.CreateOrder(ctx, order); err != nil {
+	if order.Version == 0 {
+		order.Version = 1
+	}
+	order.Status = models.StatusCreated
+
+	err = s.repo.CreateOrder(ctx, order)
+	if errors.Is(err, repository.ErrOrderExists) {
+		return s.handleDuplicate(ctx, order)
+	}
+	if err != nil {
 		return err
 	}
-	s.cache.Set(order.OrderUID, *order)
+	s.loads.invalidate(order.OrderUID)
+	s.notFound.remove(order.OrderUID)
+	s.cacheSet(ctx, order)
 	return nil
 }
+
+// ValidateOrder checks order as CreateOrder does, without storing it.
+func (s *Service) ValidateOrder(ctx context.Context, order *models.Order) error {
+	return s.validate(logging.With(ctx, logging.KeyOrderUID, order.OrderUID), order)
+}
+
+// validate checks the order against the struct tags and the business rules.
+// Rules in warn mode are only logged.
+func (s *Service) validate(ctx context.Context, order *models.Order) (err error) {
+	ctx, span := startSpan(ctx, "validate", order.OrderUID)
+	defer tracing.End(span, &err)
+
+	if err = s.validator.Struct(order); err != nil {
+		return fmt.Errorf("%w: %w", apperrors.ErrValidation, err)
+	}
+
+	warnings, err := s.rules.Validate(order)
+	for _, w := range warnings {
+		s.logger.WarnContext(ctx, "business rule violated",
+			"rule", w.Rule, "field", w.Field, "message", w.Message)
+	}
+	return err
+}
+
+// handleDuplicate resolves an order whose order_uid is already stored by
+// comparing versions: newer ones are applied, the same version must carry an
+// identical payload and older ones are rejected.
+func (s *Service) handleDuplicate(ctx context.Context, order *models.Order) error {
+	existing, err := s.repo.GetOrderByID(ctx, order.OrderUID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case order.Version > existing.Version:
+		order.Status = existing.Status
+		return s.updateOrder(ctx, order)
+	case order.Version < existing.Version:
+		return &StaleVersionError{OrderUID: order.OrderUID, Version: order.Version}
+	case !sameOrder(existing, order):
+		return &OrderConflictError{OrderUID: order.OrderUID}
+	}
+
+	s.cacheSet(ctx, existing)
+	return nil
+}
+
+func (s *Service) updateOrder(ctx context.Context, order *models.Order) error {
+	err := s.repo.UpdateOrder(ctx, order)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return &StaleVersionError{OrderUID: order.OrderUID, Version: order.Version}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "order updated", "version", order.Version)
+	s.loads.invalidate(order.OrderUID)
+	s.cacheSet(ctx, order)
+	return nil
+}
+
+func (s *Service) SearchOrders(ctx context.Context, filter models.OrderFilter) (_ *models.OrderPage, err error) {
+	ctx, span := startSpan(ctx, "SearchOrders", "")
+	defer tracing.End(span, &err)
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	filter.Limit = min(filter.Limit, maxPageSize)
+
+	pageSize := filter.Limit
+	// Fetch one extra order to find out whether there is a next page.
+	filter.Limit++
+
+	orders, err := s.repo.SearchOrders(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		last := page.Orders[pageSize-1]
+		page.NextCursor = models.OrderCursor{
+			DateCreated: last.DateCreated,
+			OrderUID:    last.OrderUID,
+		}.Encode()
+	}
+
+	return page, nil
+}
+
+func (s *Service) CacheStats() cache.Stats {
+	return s.cache.Stats()
+}
+
+func (s *Service) EvictFromCache(orderID string) {
+	s.cache.Delete(orderID)
+}
+
+// ReloadCache drops every cached order and repeats the warm-up with the limit
+// of the last LoadCache call.
+func (s *Service) ReloadCache(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "ReloadCache", "")
+	defer tracing.End(span, &err)
+
+	s.cache.Purge()
+	return s.LoadCache(ctx, int(s.warmLimit.Load()))
+}
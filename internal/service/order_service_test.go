@@ -3,19 +3,26 @@ package service_test
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"order-service-wb/internal/apperrors"
 	"order-service-wb/internal/models"
+	"order-service-wb/internal/repository"
 	"order-service-wb/internal/service"
 	"order-service-wb/mocks"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestGetOrderByID_CacheHit(t *testing.T) {
 	t.Parallel()
 
@@ -26,7 +33,7 @@ func TestGetOrderByID_CacheHit(t *testing.T) {
 
 	mockCache.On("Get", "123").Return(testOrder, true)
 
-	srv := service.NewOrderService(mockRepo, mockCache)
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
 
 	order, err := srv.GetOrderByID(context.Background(), "123")
 
@@ -49,7 +56,7 @@ func TestGetOrderByID_CacheMissDBHit(t *testing.T) {
 	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(testOrder, nil)
 	mockCache.On("Set", "123", *testOrder).Return()
 
-	srv := service.NewOrderService(mockRepo, mockCache)
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
 
 	order, err := srv.GetOrderByID(context.Background(), "123")
 
@@ -60,6 +67,180 @@ func TestGetOrderByID_CacheMissDBHit(t *testing.T) {
 	mockCache.AssertExpectations(t)
 }
 
+func TestGetOrderByID_CoalescesConcurrentMisses(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	testOrder := &models.Order{OrderUID: "123"}
+	release := make(chan struct{})
+
+	mockCache.On("Get", "123").Return(models.Order{}, false)
+	mockRepo.On("GetOrderByID", mock.Anything, "123").
+		Run(func(mock.Arguments) { <-release }).
+		Return(testOrder, nil).
+		Once()
+	mockCache.On("Set", "123", *testOrder).Return().Once()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan *models.Order, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := srv.GetOrderByID(context.Background(), "123")
+			assert.NoError(t, err)
+			results <- order
+		}()
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	wg.Wait()
+	close(results)
+
+	for order := range results {
+		assert.Equal(t, testOrder, order)
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "GetOrderByID", 1)
+	mockCache.AssertExpectations(t)
+}
+
+func TestGetOrderByID_CallerCancellation(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	mockCache.On("Get", "123").Return(models.Order{}, false)
+	mockRepo.On("GetOrderByID", mock.Anything, "123").
+		Run(func(mock.Arguments) { <-release }).
+		Return(nil, fmt.Errorf("order 123: %w", apperrors.ErrNotFound))
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := srv.GetOrderByID(ctx, "123")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestGetOrderByID_NegativeCache(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	mockCache.On("Get", "missing").Return(models.Order{}, false)
+	mockRepo.On("GetOrderByID", mock.Anything, "missing").
+		Return(nil, fmt.Errorf("order missing: %w", apperrors.ErrNotFound)).
+		Once()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, time.Minute, discardLogger)
+
+	for range 3 {
+		_, err := srv.GetOrderByID(context.Background(), "missing")
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "GetOrderByID", 1)
+}
+
+func TestGetOrderByID_LoadDoesNotOverwriteStatusChange(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	stored := generateFakeOrder("123")
+	changed := *stored
+	changed.Status = models.StatusPaid
+
+	mockCache.On("Get", "123").Return(models.Order{}, false)
+	mockRepo.On("GetOrderByID", mock.Anything, "123").
+		Run(func(mock.Arguments) {
+			close(loading)
+			<-release
+		}).
+		Return(stored, nil).
+		Once()
+	current := *stored
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(&current, nil).Once()
+	mockRepo.On("UpdateOrderStatus", mock.Anything, "123", models.StatusCreated, models.StatusPaid).Return(nil)
+	mockCache.On("Set", "123", changed).Return().Once()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	loaded := make(chan *models.Order)
+	go func() {
+		order, err := srv.GetOrderByID(context.Background(), "123")
+		assert.NoError(t, err)
+		loaded <- order
+	}()
+
+	<-loading
+	_, err := srv.ChangeOrderStatus(context.Background(), "123", models.StatusPaid)
+	require.NoError(t, err)
+	close(release)
+
+	assert.Equal(t, models.StatusCreated, (<-loaded).Status)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNumberOfCalls(t, "Set", 1)
+}
+
+func TestGetOrderByID_LoadDoesNotHideCreatedOrder(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	created := generateFakeOrder("123")
+
+	mockCache.On("Get", "123").Return(models.Order{}, false)
+	mockRepo.On("GetOrderByID", mock.Anything, "123").
+		Run(func(mock.Arguments) {
+			close(loading)
+			<-release
+		}).
+		Return(nil, fmt.Errorf("order 123: %w", apperrors.ErrNotFound)).
+		Once()
+	mockRepo.On("CreateOrder", mock.Anything, created).Return(nil)
+	mockCache.On("Set", "123", *created).Return()
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(created, nil).Once()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, time.Minute, discardLogger)
+
+	loaded := make(chan error)
+	go func() {
+		_, err := srv.GetOrderByID(context.Background(), "123")
+		loaded <- err
+	}()
+
+	<-loading
+	require.NoError(t, srv.CreateOrder(context.Background(), created))
+	close(release)
+	assert.ErrorIs(t, <-loaded, apperrors.ErrNotFound)
+
+	order, err := srv.GetOrderByID(context.Background(), "123")
+
+	require.NoError(t, err)
+	assert.Equal(t, created.OrderUID, order.OrderUID)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestCreateOrder_Success(t *testing.T) {
 	t.Parallel()
 
@@ -71,7 +252,7 @@ func TestCreateOrder_Success(t *testing.T) {
 	mockRepo.On("CreateOrder", mock.Anything, testOrder).Return(nil)
 	mockCache.On("Set", "123", *testOrder)
 
-	srv := service.NewOrderService(mockRepo, mockCache)
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
 
 	err := srv.CreateOrder(context.Background(), testOrder)
 
@@ -81,6 +262,28 @@ func TestCreateOrder_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateOrder_IgnoresPayloadStatus(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	testOrder := generateFakeOrder("123")
+	testOrder.Status = models.StatusDelivered
+
+	mockRepo.On("CreateOrder", mock.Anything, mock.MatchedBy(func(order *models.Order) bool {
+		return order.Status == models.StatusCreated
+	})).Return(nil)
+	mockCache.On("Set", "123", mock.Anything)
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	require.NoError(t, srv.CreateOrder(context.Background(), testOrder))
+	assert.Equal(t, models.StatusCreated, testOrder.Status)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestCreateOrder_FailedValidate(t *testing.T) {
 	t.Parallel()
 
@@ -89,7 +292,7 @@ func TestCreateOrder_FailedValidate(t *testing.T) {
 
 	testOrder := &models.Order{OrderUID: "123"}
 
-	srv := service.NewOrderService(mockRepo, mockCache)
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
 
 	err := srv.CreateOrder(context.Background(), testOrder)
 
@@ -109,7 +312,7 @@ func TestCreateOrder_FailedDB(t *testing.T) {
 
 	mockRepo.On("CreateOrder", mock.Anything, testOrder).Return(fmt.Errorf("error"))
 
-	srv := service.NewOrderService(mockRepo, mockCache)
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
 
 	err := srv.CreateOrder(context.Background(), testOrder)
 
@@ -119,6 +322,185 @@ func TestCreateOrder_FailedDB(t *testing.T) {
 	mockCache.AssertNotCalled(t, "Set")
 }
 
+func TestCreateOrder_DuplicateIdentical(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	testOrder := generateFakeOrder("123")
+	stored := *testOrder
+	stored.DateCreated = testOrder.DateCreated.Local()
+
+	mockRepo.On("CreateOrder", mock.Anything, testOrder).
+		Return(fmt.Errorf("order 123: %w", repository.ErrOrderExists))
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(&stored, nil)
+	mockCache.On("Set", "123", stored).Return()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	err := srv.CreateOrder(context.Background(), testOrder)
+
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCreateOrder_DuplicateConflict(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	testOrder := generateFakeOrder("123")
+	stored := *testOrder
+	stored.Payment.Amount = 2000
+
+	mockRepo.On("CreateOrder", mock.Anything, testOrder).
+		Return(fmt.Errorf("order 123: %w", repository.ErrOrderExists))
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(&stored, nil)
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	err := srv.CreateOrder(context.Background(), testOrder)
+
+	var conflictErr *service.OrderConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, "123", conflictErr.OrderUID)
+
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestCreateOrder_NewerVersionUpdates(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	stored := generateFakeOrder("123")
+	stored.Version = 1
+	testOrder := *stored
+	testOrder.Version = 2
+	testOrder.Delivery.Addr = "456 Other St"
+
+	mockRepo.On("CreateOrder", mock.Anything, &testOrder).
+		Return(fmt.Errorf("order 123: %w", repository.ErrOrderExists))
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(stored, nil)
+	mockRepo.On("UpdateOrder", mock.Anything, &testOrder).Return(nil)
+	mockCache.On("Set", "123", testOrder).Return()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	err := srv.CreateOrder(context.Background(), &testOrder)
+
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCreateOrder_StaleVersion(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	stored := generateFakeOrder("123")
+	stored.Version = 3
+	testOrder := *stored
+	testOrder.Version = 2
+
+	mockRepo.On("CreateOrder", mock.Anything, &testOrder).
+		Return(fmt.Errorf("order 123: %w", repository.ErrOrderExists))
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(stored, nil)
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	err := srv.CreateOrder(context.Background(), &testOrder)
+
+	var staleErr *service.StaleVersionError
+	assert.ErrorAs(t, err, &staleErr)
+
+	mockRepo.AssertNotCalled(t, "UpdateOrder")
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestChangeOrderStatus_Success(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	stored := generateFakeOrder("123")
+	expected := *stored
+	expected.Status = models.StatusPaid
+
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(stored, nil)
+	mockRepo.On("UpdateOrderStatus", mock.Anything, "123", models.StatusCreated, models.StatusPaid).Return(nil)
+	mockCache.On("Set", "123", expected).Return()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	order, err := srv.ChangeOrderStatus(context.Background(), "123", models.StatusPaid)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPaid, order.Status)
+
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestChangeOrderStatus_InvalidTransition(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	stored := generateFakeOrder("123")
+	stored.Status = models.StatusDelivered
+
+	mockRepo.On("GetOrderByID", mock.Anything, "123").Return(stored, nil)
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	_, err := srv.ChangeOrderStatus(context.Background(), "123", models.StatusPaid)
+
+	var transitionErr *service.InvalidTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, models.StatusDelivered, transitionErr.From)
+
+	mockRepo.AssertNotCalled(t, "UpdateOrderStatus")
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestSearchOrders_NextCursor(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	orders := []*models.Order{generateFakeOrder("3"), generateFakeOrder("2"), generateFakeOrder("1")}
+
+	mockRepo.On("SearchOrders", mock.Anything, models.OrderFilter{CustomerID: "testuser", Limit: 3}).
+		Return(orders, nil)
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	page, err := srv.SearchOrders(context.Background(), models.OrderFilter{CustomerID: "testuser", Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 2)
+
+	cursor, err := models.DecodeOrderCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", cursor.OrderUID)
+	assert.True(t, orders[1].DateCreated.Equal(cursor.DateCreated))
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestLoadCache_Success(t *testing.T) {
 	t.Parallel()
 
@@ -130,7 +512,7 @@ func TestLoadCache_Success(t *testing.T) {
 	mockRepo.On("GetAllOrders", mock.Anything, 1).Return([]*models.Order{testOrder}, nil)
 	mockCache.On("Set", "123", *testOrder).Return()
 
-	srv := service.NewOrderService(mockRepo, mockCache)
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
 
 	err := srv.LoadCache(context.Background(), 1)
 
@@ -153,6 +535,8 @@ func generateFakeOrder(id string) *models.Order {
 		SmID:        rand.Intn(100),
 		DateCreated: time.Now().UTC(),
 		OofShard:    "1",
+		Version:     1,
+		Status:      models.StatusCreated,
 		Delivery: models.Delivery{
 			Name:   "Test User",
 			Phone:  "+1234567890",
@@ -200,3 +584,24 @@ func randSeq(n int) string {
 	}
 	return string(b)
 }
+
+func TestReloadCache_RepeatsWarmUp(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := new(mocks.OrderRepository)
+	mockCache := new(mocks.Cache)
+
+	testOrder := &models.Order{OrderUID: "123"}
+
+	mockRepo.On("GetAllOrders", mock.Anything, 5).Return([]*models.Order{testOrder}, nil).Twice()
+	mockCache.On("Set", "123", *testOrder).Return().Twice()
+	mockCache.On("Purge").Return().Once()
+
+	srv := service.NewOrderService(mockRepo, mockCache, nil, 0, discardLogger)
+
+	assert.NoError(t, srv.LoadCache(context.Background(), 5))
+	assert.NoError(t, srv.ReloadCache(context.Background()))
+
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
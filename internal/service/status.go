@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"order-service-wb/internal/logging"
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/repository"
+	"order-service-wb/internal/tracing"
+)
+
+// transitions lists the statuses an order may move to from each status.
+// Statuses without an entry are terminal.
+var transitions = map[models.OrderStatus][]models.OrderStatus{
+	models.StatusCreated:    {models.StatusPaid, models.StatusCancelled},
+	models.StatusPaid:       {models.StatusAssembling, models.StatusCancelled},
+	models.StatusAssembling: {models.StatusShipped, models.StatusCancelled},
+	models.StatusShipped:    {models.StatusDelivered, models.StatusReturned},
+	models.StatusDelivered:  {models.StatusReturned},
+}
+
+func canTransition(from, to models.OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) ChangeOrderStatus(ctx context.Context, orderID string, status models.OrderStatus) (_ *models.Order, err error) {
+	ctx, span := startSpan(ctx, "ChangeOrderStatus", orderID)
+	defer tracing.End(span, &err)
+
+	if !status.Valid() {
+		return nil, &UnknownStatusError{Status: status}
+	}
+
+	ctx = logging.With(ctx, logging.KeyOrderUID, orderID)
+
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canTransition(order.Status, status) {
+		return nil, &InvalidTransitionError{OrderUID: orderID, From: order.Status, To: status}
+	}
+
+	err = s.repo.UpdateOrderStatus(ctx, orderID, order.Status, status)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return nil, &InvalidTransitionError{OrderUID: orderID, From: order.Status, To: status, Concurrent: true}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "order status changed", "from", order.Status, "to", status)
+	order.Status = status
+	s.loads.invalidate(orderID)
+	s.cacheSet(ctx, order)
+	return order, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"order-service-wb/internal/models"
+	"order-service-wb/internal/tracing"
+)
+
+var tracer = tracing.Tracer("order-service-wb/internal/service")
+
+const attrOrderUID = attribute.Key("order.uid")
+
+func startSpan(ctx context.Context, name, orderID string) (context.Context, trace.Span) {
+	if orderID == "" {
+		return tracer.Start(ctx, "OrderService."+name)
+	}
+	return tracer.Start(ctx, "OrderService."+name, trace.WithAttributes(attrOrderUID.String(orderID)))
+}
+
+func (s *Service) cacheGet(ctx context.Context, orderID string) (models.Order, bool) {
+	_, span := tracer.Start(ctx, "cache.Get", trace.WithAttributes(attrOrderUID.String(orderID)))
+	defer span.End()
+
+	order, ok := s.cache.Get(orderID)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	return order, ok
+}
+
+func (s *Service) cacheSet(ctx context.Context, order *models.Order) {
+	_, span := tracer.Start(ctx, "cache.Set", trace.WithAttributes(attrOrderUID.String(order.OrderUID)))
+	defer span.End()
+
+	s.cache.Set(order.OrderUID, *order)
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN status VARCHAR NOT NULL DEFAULT 'created';
+
+CREATE TABLE order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid VARCHAR NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    from_status VARCHAR,
+    to_status VARCHAR NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX order_status_history_order_uid_idx ON order_status_history(order_uid, changed_at);
+
+INSERT INTO order_status_history(order_uid, from_status, to_status, changed_at)
+SELECT order_uid, NULL, status, date_created FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_status_history;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
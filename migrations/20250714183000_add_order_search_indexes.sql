@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX orders_date_created_order_uid_idx ON orders(date_created DESC, order_uid DESC);
+CREATE INDEX orders_customer_id_idx ON orders(customer_id, date_created DESC, order_uid DESC);
+CREATE INDEX orders_track_number_idx ON orders(track_number);
+CREATE INDEX orders_delivery_service_idx ON orders(delivery_service);
+CREATE INDEX items_order_uid_idx ON items(order_uid);
+CREATE INDEX items_brand_idx ON items(brand, order_uid);
+CREATE INDEX payment_provider_currency_idx ON payment(provider, currency);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS payment_provider_currency_idx;
+DROP INDEX IF EXISTS items_brand_idx;
+DROP INDEX IF EXISTS items_order_uid_idx;
+DROP INDEX IF EXISTS orders_delivery_service_idx;
+DROP INDEX IF EXISTS orders_track_number_idx;
+DROP INDEX IF EXISTS orders_customer_id_idx;
+DROP INDEX IF EXISTS orders_date_created_order_uid_idx;
+-- +goose StatementEnd
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	cache "order-service-wb/internal/cache"
 	models "order-service-wb/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,6 +14,11 @@ type Cache struct {
 	mock.Mock
 }
 
+// Delete provides a mock function with given fields: id
+func (_m *Cache) Delete(id string) {
+	_m.Called(id)
+}
+
 // Get provides a mock function with given fields: id
 func (_m *Cache) Get(id string) (models.Order, bool) {
 	ret := _m.Called(id)
@@ -41,11 +47,52 @@ func (_m *Cache) Get(id string) (models.Order, bool) {
 	return r0, r1
 }
 
+// Len provides a mock function with no fields
+func (_m *Cache) Len() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Len")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Purge provides a mock function with no fields
+func (_m *Cache) Purge() {
+	_m.Called()
+}
+
 // Set provides a mock function with given fields: id, order
 func (_m *Cache) Set(id string, order models.Order) {
 	_m.Called(id, order)
 }
 
+// Stats provides a mock function with no fields
+func (_m *Cache) Stats() cache.Stats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 cache.Stats
+	if rf, ok := ret.Get(0).(func() cache.Stats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(cache.Stats)
+	}
+
+	return r0
+}
+
 // NewCache creates a new instance of Cache. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCache(t interface {
@@ -4,16 +4,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DbConfig DbConfig     `mapstructure:"db"`
-	Server   ServerConfig `mapstructure:"server"`
-	Cache    CacheConfig  `mapstructure:"cache"`
-	Kafka    KafkaConfig  `mapstructure:"kafka"`
+	DbConfig DbConfig       `mapstructure:"db"`
+	Server   ServerConfig   `mapstructure:"server"`
+	Cache    CacheConfig    `mapstructure:"cache"`
+	Kafka    KafkaConfig    `mapstructure:"kafka"`
+	Outbox   OutboxConfig   `mapstructure:"outbox"`
+	Schemas  SchemasConfig  `mapstructure:"schemas"`
+	Log      LogConfig      `mapstructure:"log"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Shutdown ShutdownConfig `mapstructure:"shutdown"`
+
+	Validation ValidationConfig `mapstructure:"validation"`
 }
 
 type DbConfig struct {
@@ -27,16 +35,86 @@ type DbConfig struct {
 
 type ServerConfig struct {
 	Port string `mapstructure:"port"`
+	// AdminToken guards the /admin routes; empty disables them. It is read
+	// from the ADMIN_TOKEN environment variable.
+	AdminToken string `mapstructure:"admin_token"`
+
+	HealthTimeout time.Duration `mapstructure:"health_timeout"`
 }
 
 type CacheConfig struct {
-	Size int `mapstructure:"size"`
+	Size     int           `mapstructure:"size"`
+	Policy   string        `mapstructure:"policy"`
+	Shards   int           `mapstructure:"shards"`
+	TTL      time.Duration `mapstructure:"ttl"`
+	MaxBytes int64         `mapstructure:"max_bytes"`
+
+	NotFoundTTL time.Duration `mapstructure:"not_found_ttl"`
 }
 
 type KafkaConfig struct {
 	Broker string `mapstructure:"broker"`
 	Topic  string `mapstructure:"topic"`
 	Group  string `mapstructure:"group"`
+
+	DeadLetterTopic string `mapstructure:"dead_letter_topic"`
+
+	Retry  RetryConfig  `mapstructure:"retry"`
+	Commit CommitConfig `mapstructure:"commit"`
+}
+
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+	Jitter      float64       `mapstructure:"jitter"`
+}
+
+type OutboxConfig struct {
+	Topic    string        `mapstructure:"topic"`
+	Interval time.Duration `mapstructure:"interval"`
+	Batch    int           `mapstructure:"batch"`
+}
+
+// SchemasConfig points at the directory of the file schema registry.
+type SchemasConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+type LogConfig struct {
+	Format string `mapstructure:"format"`
+	Level  string `mapstructure:"level"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	File        string  `mapstructure:"file"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// ShutdownConfig holds the timeout of each shutdown phase.
+type ShutdownConfig struct {
+	Consumer time.Duration `mapstructure:"consumer"`
+	HTTP     time.Duration `mapstructure:"http"`
+	Outbox   time.Duration `mapstructure:"outbox"`
+	Producer time.Duration `mapstructure:"producer"`
+	Tracing  time.Duration `mapstructure:"tracing"`
+	Database time.Duration `mapstructure:"database"`
+}
+
+type CommitConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+	Batch    int           `mapstructure:"batch"`
+}
+
+type ValidationConfig struct {
+	Rules map[string]RuleConfig `mapstructure:"rules"`
+}
+
+type RuleConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Mode    string `mapstructure:"mode"`
 }
 
 func NewConfig() *Config {
@@ -52,6 +130,7 @@ func NewConfig() *Config {
 
 	viper.SetConfigName(configName)
 	viper.AddConfigPath(configPath)
+	_ = viper.BindEnv("server.admin_token", "ADMIN_TOKEN")
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatal("Error reading config file")
 	}